@@ -0,0 +1,67 @@
+package main
+
+import (
+	"company-crud/pkg/http_server"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func (s *Suite) testAccessLogHttpCases(t *testing.T) {
+	t.Run("Request id is echoed and logged - with token", func(t *testing.T) {
+		requestID := "test-request-id-1"
+
+		_, headers, status := s.testClientRequest(t, http.MethodGet, s.token, "http://localhost:8000/companies/unknownCompany",
+			map[string]string{http_server.RequestIDHeader: requestID}, nil)
+		require.Equal(t, http.StatusNotFound, status)
+		require.Equal(t, requestID, headers.Get(http_server.RequestIDHeader))
+
+		entries := s.logs.FilterField(zap.String("request_id", requestID)).FilterMessage("request served").All()
+		require.Len(t, entries, 1)
+
+		fields := entries[0].ContextMap()
+		require.Equal(t, http.MethodGet, fields["method"])
+		require.Equal(t, "/companies/{company_name}", fields["route"])
+		require.EqualValues(t, http.StatusNotFound, fields["status"])
+		require.NotZero(t, fields["bytes"])
+		require.NotEmpty(t, fields["remote_addr"])
+	})
+
+	t.Run("Unmatched requests are tagged and logged - with token", func(t *testing.T) {
+		requestID := "test-request-id-2"
+
+		_, headers, status := s.testClientRequest(t, http.MethodGet, s.token, "http://localhost:8000/unknownPath",
+			map[string]string{http_server.RequestIDHeader: requestID}, nil)
+		require.Equal(t, http.StatusNotFound, status)
+		require.Equal(t, requestID, headers.Get(http_server.RequestIDHeader))
+
+		entries := s.logs.FilterField(zap.String("request_id", requestID)).FilterMessage("request served").All()
+		require.Len(t, entries, 1)
+
+		fields := entries[0].ContextMap()
+		require.Equal(t, "unknown", fields["route"])
+		require.EqualValues(t, http.StatusNotFound, fields["status"])
+	})
+
+	t.Run("Request id is generated when missing - with token", func(t *testing.T) {
+		_, headers, status := s.testClientRequest(t, http.MethodGet, s.token, "http://localhost:8000/companies", nil, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		_, err := uuid.Parse(headers.Get(http_server.RequestIDHeader))
+		require.NoError(t, err)
+	})
+
+	t.Run("Oversized request id is replaced - with token", func(t *testing.T) {
+		requestID := strings.Repeat("a", 200)
+
+		_, headers, status := s.testClientRequest(t, http.MethodGet, s.token, "http://localhost:8000/companies",
+			map[string]string{http_server.RequestIDHeader: requestID}, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		_, err := uuid.Parse(headers.Get(http_server.RequestIDHeader))
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"company-crud/internal/domain"
+	jsons "company-crud/internal/handlers/http"
+	"company-crud/pkg/http_server"
+	pkgPg "company-crud/pkg/postres"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func (s *Suite) testAuditHttpCases(t *testing.T, pg *pkgPg.Postgres) {
+	t.Run("Mutations are audited - with token", func(t *testing.T) {
+		start := time.Now().Add(-time.Second)
+
+		employees := 2
+		registered := true
+		jsonData, err := json.Marshal(jsons.Create{
+			Name:            "testNameAudit",
+			Description:     "description_1",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		})
+		require.NoError(t, err)
+
+		_, _, status := s.testClientRequest(t, http.MethodPost, s.token, "http://localhost:8000/companies",
+			map[string]string{http_server.RequestIDHeader: "audit-create"}, jsonData)
+		require.Equal(t, http.StatusCreated, status)
+
+		jsonData, err = json.Marshal(jsons.Patch{Name: "testNameAudit2"})
+		require.NoError(t, err)
+
+		_, _, status = s.testClientRequest(t, http.MethodPatch, s.token, "http://localhost:8000/companies/testNameAudit",
+			map[string]string{http_server.RequestIDHeader: "audit-patch"}, jsonData)
+		require.Equal(t, http.StatusOK, status)
+
+		_, _, status = s.testClientRequest(t, http.MethodDelete, s.token, "http://localhost:8000/companies/testNameAudit2",
+			map[string]string{http_server.RequestIDHeader: "audit-delete"}, nil)
+		require.Equal(t, http.StatusOK, status)
+
+		// The history is found through the name the company had at any time.
+		for _, name := range []string{"testNameAudit", "testNameAudit2"} {
+			resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/"+name+"/audit")
+			require.Equal(t, http.StatusOK, status)
+
+			audit := jsons.AuditList{}
+			err = json.Unmarshal(resp, &audit)
+			require.NoError(t, err)
+			require.Len(t, audit.Entries, 3)
+			require.Empty(t, audit.NextCursor)
+
+			deleted, updated, created := audit.Entries[0], audit.Entries[1], audit.Entries[2]
+			require.Equal(t, string(domain.AuditDelete), deleted.Operation)
+			require.Equal(t, "audit-delete", deleted.RequestID)
+			require.NotEmpty(t, deleted.Before)
+			require.Empty(t, deleted.After)
+
+			require.Equal(t, string(domain.AuditUpdate), updated.Operation)
+			require.Equal(t, "audit-patch", updated.RequestID)
+			require.Equal(t, "testNameAudit2", updated.CompanyName)
+
+			before := map[string]any{}
+			require.NoError(t, json.Unmarshal(updated.Before, &before))
+			require.Equal(t, "testNameAudit", before["name"])
+
+			after := map[string]any{}
+			require.NoError(t, json.Unmarshal(updated.After, &after))
+			require.Equal(t, "testNameAudit2", after["name"])
+
+			require.Equal(t, string(domain.AuditCreate), created.Operation)
+			require.Equal(t, "audit-create", created.RequestID)
+			require.Empty(t, created.Before)
+			require.NotEmpty(t, created.After)
+
+			for _, entry := range audit.Entries {
+				require.Equal(t, "integration-test", entry.Actor)
+				require.Equal(t, created.CompanyID, entry.CompanyID)
+			}
+		}
+
+		// Paging through the global log in the time range.
+		query := url.Values{"from": {start.UTC().Format(time.RFC3339)}, "limit": {"1"}}
+		seen := 0
+		for {
+			resp, status := s.testClientGet(t, s.token, "http://localhost:8000/audit?"+query.Encode())
+			require.Equal(t, http.StatusOK, status)
+
+			audit := jsons.AuditList{}
+			err = json.Unmarshal(resp, &audit)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(audit.Entries), 1)
+			seen += len(audit.Entries)
+
+			if audit.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", audit.NextCursor)
+		}
+		require.GreaterOrEqual(t, seen, 3)
+
+		query = url.Values{"from": {time.Now().Add(time.Hour).UTC().Format(time.RFC3339)}}
+		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/audit?"+query.Encode())
+		require.Equal(t, http.StatusOK, status)
+
+		audit := jsons.AuditList{}
+		err = json.Unmarshal(resp, &audit)
+		require.NoError(t, err)
+		require.Empty(t, audit.Entries)
+	})
+
+	t.Run("Audit log is append-only", func(t *testing.T) {
+		_, err := pg.Exec(`UPDATE xm_assessment.company_audit SET actor = 'someone-else'`)
+		require.Error(t, err)
+
+		_, err = pg.Exec(`DELETE FROM xm_assessment.company_audit`)
+		require.Error(t, err)
+	})
+
+	t.Run("Audit requires the audit scope", func(t *testing.T) {
+		token := s.signToken(t, domain.ScopeCompaniesRead)
+
+		_, status := s.testClientGet(t, token, "http://localhost:8000/audit")
+		require.Equal(t, http.StatusForbidden, status)
+
+		_, status = s.testClientGet(t, token, "http://localhost:8000/companies/testNameAudit/audit")
+		require.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Invalid audit parameters", func(t *testing.T) {
+		_, status := s.testClientGet(t, s.token, "http://localhost:8000/audit?from=yesterday")
+		require.Equal(t, http.StatusBadRequest, status)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/audit?cursor=invalid")
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"company-crud/internal/domain"
+	jsons "company-crud/internal/handlers/http"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func (s *Suite) testAuthHttpCases(t *testing.T) {
+	t.Run("Read scope only grants reads", func(t *testing.T) {
+		token := s.signToken(t, domain.ScopeCompaniesRead)
+
+		_, status := s.testClientGet(t, token, "http://localhost:8000/companies")
+		require.Equal(t, http.StatusOK, status)
+
+		employees := 2
+		registered := true
+		jsonData, err := json.Marshal(jsons.Create{
+			Name:            "testNameAuth_1",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		})
+		require.NoError(t, err)
+
+		resp, status := s.testClientPost(t, token, "http://localhost:8000/companies", jsonData)
+		require.Equal(t, http.StatusForbidden, status)
+
+		problem := jsons.Problem{}
+		err = json.Unmarshal(resp, &problem)
+		require.NoError(t, err)
+		require.Equal(t, "/problems/forbidden", problem.Type)
+
+		_, status = s.testClientDelete(t, token, "http://localhost:8000/companies/testNameAuth_1")
+		require.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Write scope doesn't grant deletes", func(t *testing.T) {
+		token := s.signToken(t, domain.ScopeCompaniesWrite)
+
+		_, status := s.testClientDelete(t, token, "http://localhost:8000/companies/testNameAuth_1")
+		require.Equal(t, http.StatusForbidden, status)
+
+		_, status = s.testClientGet(t, token, "http://localhost:8000/companies")
+		require.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Roles claim grants scopes", func(t *testing.T) {
+		token := s.signTokenWithClaims(t, jwt.MapClaims{
+			"iss":   s.tokenCfg.TokenIssuer,
+			"aud":   s.tokenCfg.TokenAudience,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"roles": []string{domain.ScopeCompaniesRead},
+		})
+
+		_, status := s.testClientGet(t, token, "http://localhost:8000/companies")
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("Rejected tokens", func(t *testing.T) {
+		valid := jwt.MapClaims{
+			"iss":   s.tokenCfg.TokenIssuer,
+			"aud":   s.tokenCfg.TokenAudience,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": domain.ScopeCompaniesRead,
+		}
+		with := func(key string, value any) jwt.MapClaims {
+			claims := jwt.MapClaims{}
+			for k, v := range valid {
+				claims[k] = v
+			}
+			if value == nil {
+				delete(claims, key)
+			} else {
+				claims[key] = value
+			}
+
+			return claims
+		}
+
+		cases := []struct {
+			name   string
+			token  string
+			detail string
+		}{
+			{"expired", s.signTokenWithClaims(t, with("exp", time.Now().Add(-time.Hour).Unix())), "token expired"},
+			{"not yet valid", s.signTokenWithClaims(t, with("nbf", time.Now().Add(time.Hour).Unix())), "token not valid yet"},
+			{"without expiration", s.signTokenWithClaims(t, with("exp", nil)), "token without expiration"},
+			{"other issuer", s.signTokenWithClaims(t, with("iss", "someone-else")), "invalid token issuer"},
+			{"other audience", s.signTokenWithClaims(t, with("aud", "someone-else")), "invalid token audience"},
+			{"bad signature", s.token + "x", "invalid token"},
+		}
+
+		for _, c := range cases {
+			resp, status := s.testClientGet(t, c.token, "http://localhost:8000/companies")
+			require.Equal(t, http.StatusUnauthorized, status, c.name)
+
+			problem := jsons.Problem{}
+			err := json.Unmarshal(resp, &problem)
+			require.NoError(t, err)
+			require.Equal(t, c.detail, problem.Detail, c.name)
+		}
+	})
+
+	t.Run("Legacy Token header", func(t *testing.T) {
+		_, _, status := s.testClientRequest(t, http.MethodGet, "", "http://localhost:8000/companies",
+			map[string]string{"Token": s.token}, nil)
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("Challenges", func(t *testing.T) {
+		cases := []struct {
+			name          string
+			authorization string
+			status        int
+			challenge     string
+		}{
+			{"missing token", "", http.StatusUnauthorized, `Bearer realm="company-crud"`},
+			{"other scheme", "Basic dXNlcjpwYXNzd2Q=", http.StatusUnauthorized, `Bearer realm="company-crud", error="invalid_request", error_description="malformed Authorization header"`},
+			{"invalid token", "Bearer " + s.token + "x", http.StatusUnauthorized, `Bearer realm="company-crud", error="invalid_token", error_description="invalid token"`},
+			{"insufficient scope", "bearer " + s.signToken(t, domain.ScopeCompaniesWrite), http.StatusForbidden, `Bearer realm="company-crud", error="insufficient_scope", scope="companies:read"`},
+		}
+
+		for _, c := range cases {
+			headers := map[string]string{}
+			if c.authorization != "" {
+				headers["Authorization"] = c.authorization
+			}
+
+			_, respHeaders, status := s.testClientRequest(t, http.MethodGet, "", "http://localhost:8000/companies", headers, nil)
+			require.Equal(t, c.status, status, c.name)
+			require.Equal(t, c.challenge, respHeaders.Get("WWW-Authenticate"), c.name)
+		}
+	})
+}
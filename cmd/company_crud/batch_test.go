@@ -0,0 +1,223 @@
+package main
+
+import (
+	"company-crud/internal/events"
+	jsons "company-crud/internal/handlers/http"
+	pkgPg "company-crud/pkg/postres"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func (s *Suite) testBatchHttpCases(t *testing.T, pg *pkgPg.Postgres) {
+	createOp := func(t *testing.T, name string) jsons.BatchOperation {
+		employees := 5
+		registered := true
+		data, err := json.Marshal(jsons.Create{
+			Name:            name,
+			Description:     "description",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "Cooperative",
+		})
+		require.NoError(t, err)
+
+		return jsons.BatchOperation{Op: "create", Data: data}
+	}
+
+	batch := func(t *testing.T, token string, request jsons.BatchRequest) (jsons.BatchResponse, int) {
+		jsonData, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		resp, status := s.testClientPost(t, token, "http://localhost:8000/companies:batch", jsonData)
+
+		// Problems, answered for the whole batch, leave the results empty.
+		response := jsons.BatchResponse{}
+		_ = json.Unmarshal(resp, &response)
+
+		return response, status
+	}
+
+	statuses := func(response jsons.BatchResponse) []int {
+		result := make([]int, 0, len(response.Results))
+		for _, item := range response.Results {
+			result = append(result, item.Status)
+		}
+
+		return result
+	}
+
+	t.Run("Atomic batch is applied - with token", func(t *testing.T) {
+		description := "patched in batch"
+		patchData, err := json.Marshal(jsons.Patch{Description: &description})
+		require.NoError(t, err)
+
+		response, status := batch(t, s.token, jsons.BatchRequest{
+			Atomic: true,
+			Operations: []jsons.BatchOperation{
+				createOp(t, "testNameBat_1"),
+				createOp(t, "testNameBat_2"),
+				{Op: "patch", Name: "testNameBat_1", Data: patchData},
+			},
+		})
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []int{http.StatusCreated, http.StatusCreated, http.StatusOK}, statuses(response))
+		require.NotNil(t, response.Results[0].ID)
+		require.Equal(t, response.Results[0].ID, response.Results[2].ID)
+
+		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameBat_1")
+		require.Equal(t, http.StatusOK, status)
+
+		company := jsons.Get{}
+		require.NoError(t, json.Unmarshal(resp, &company))
+		require.Equal(t, *response.Results[0].ID, company.ID)
+		require.Equal(t, description, company.Description)
+	})
+
+	t.Run("Atomic batch is rolled back on failure - with token", func(t *testing.T) {
+		response, status := batch(t, s.token, jsons.BatchRequest{
+			Atomic: true,
+			Operations: []jsons.BatchOperation{
+				createOp(t, "testNameBat_3"),
+				createOp(t, "testNameBat_1"),
+			},
+		})
+		require.Equal(t, http.StatusConflict, status)
+		require.Equal(t, []int{http.StatusFailedDependency, http.StatusConflict}, statuses(response))
+		require.Equal(t, "/problems/not-applied", response.Results[0].Error.Type)
+		require.Nil(t, response.Results[0].ID)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameBat_3")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Atomic batch with an invalid operation is not applied - with token", func(t *testing.T) {
+		response, status := batch(t, s.token, jsons.BatchRequest{
+			Atomic: true,
+			Operations: []jsons.BatchOperation{
+				createOp(t, "testNameBat_3"),
+				{Op: "delete"},
+			},
+		})
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+		require.Equal(t, []int{http.StatusFailedDependency, http.StatusUnprocessableEntity}, statuses(response))
+		require.NotEmpty(t, response.Results[1].Error.Violations)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameBat_3")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Best effort batch reports every operation - with token", func(t *testing.T) {
+		response, status := batch(t, s.token, jsons.BatchRequest{
+			Operations: []jsons.BatchOperation{
+				createOp(t, "testNameBat_4"),
+				{Op: "patch", Name: "testNameBatNone", Data: json.RawMessage(`{"description":"x"}`)},
+				createOp(t, "testNameBat_2"),
+				createOp(t, "testNameBatLongerThan15"),
+				{Op: "delete", Name: "testNameBat_2"},
+			},
+		})
+		require.Equal(t, http.StatusMultiStatus, status)
+		require.Equal(t, []int{
+			http.StatusCreated,
+			http.StatusNotFound,
+			http.StatusConflict,
+			http.StatusUnprocessableEntity,
+			http.StatusOK,
+		}, statuses(response))
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameBat_4")
+		require.Equal(t, http.StatusOK, status)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameBat_2")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Batch changes publish an event each by default - with token", func(t *testing.T) {
+		response, status := batch(t, s.token, jsons.BatchRequest{
+			Operations: []jsons.BatchOperation{createOp(t, "testNameBat_5")},
+		})
+		require.Equal(t, http.StatusMultiStatus, status)
+		require.Equal(t, []int{http.StatusCreated}, statuses(response))
+
+		var eventTypes []string
+		err := pg.Select(&eventTypes, `SELECT event_type FROM xm_assessment.outbox WHERE aggregate_id = $1`, *response.Results[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{string(events.CompanyCreated)}, eventTypes)
+	})
+
+	t.Run("Batch changes publish a summary event on demand - with token", func(t *testing.T) {
+		response, status := batch(t, s.token, jsons.BatchRequest{
+			Events: "summary",
+			Operations: []jsons.BatchOperation{
+				createOp(t, "testNameBat_6"),
+				{Op: "delete", Name: "testNameBat_6"},
+			},
+		})
+		require.Equal(t, http.StatusMultiStatus, status)
+		require.Equal(t, []int{http.StatusCreated, http.StatusOK}, statuses(response))
+		id := *response.Results[0].ID
+
+		var messages int
+		err := pg.Get(&messages, `SELECT COUNT(*) FROM xm_assessment.outbox WHERE aggregate_id = $1`, id)
+		require.NoError(t, err)
+		require.Zero(t, messages)
+
+		var payloads [][]byte
+		err = pg.Select(&payloads, `SELECT payload FROM xm_assessment.outbox WHERE event_type = $1 ORDER BY id`, events.CompanyBatch)
+		require.NoError(t, err)
+		require.NotEmpty(t, payloads)
+
+		event := events.BatchEvent{}
+		require.NoError(t, json.Unmarshal(payloads[len(payloads)-1], &event))
+		require.Equal(t, events.CompanyBatch, event.Type)
+		_, err = uuid.Parse(event.Subject)
+		require.NoError(t, err)
+		require.Len(t, event.Data.Changes, 2)
+		require.Equal(t, events.CompanyCreated, event.Data.Changes[0].Type)
+		require.Equal(t, events.CompanyDeleted, event.Data.Changes[1].Type)
+		require.Equal(t, id, event.Data.Changes[0].Company.ID)
+		require.Equal(t, id, event.Data.Changes[1].Company.ID)
+
+		// The audit log still records every change.
+		var entries int
+		err = pg.Get(&entries, `SELECT COUNT(*) FROM xm_assessment.company_audit WHERE company_id = $1`, id)
+		require.NoError(t, err)
+		require.Equal(t, 2, entries)
+	})
+
+	t.Run("Deleting in a batch requires the delete scope - with token", func(t *testing.T) {
+		_, status := batch(t, s.signToken(t, "companies:write"), jsons.BatchRequest{
+			Operations: []jsons.BatchOperation{{Op: "delete", Name: "testNameBat_4"}},
+		})
+		require.Equal(t, http.StatusForbidden, status)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameBat_4")
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("Invalid batches are rejected - with token", func(t *testing.T) {
+		_, status := batch(t, s.token, jsons.BatchRequest{})
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+
+		_, status = batch(t, s.token, jsons.BatchRequest{
+			Operations: []jsons.BatchOperation{{Op: "upsert", Name: "testNameBat_4"}},
+		})
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+
+		_, status = batch(t, s.token, jsons.BatchRequest{
+			Events:     "all",
+			Operations: []jsons.BatchOperation{createOp(t, "testNameBat_7")},
+		})
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+	})
+
+	t.Run("Batch - without token", func(t *testing.T) {
+		_, status := batch(t, "", jsons.BatchRequest{
+			Operations: []jsons.BatchOperation{createOp(t, "testNameBat_8")},
+		})
+		require.Equal(t, http.StatusUnauthorized, status)
+	})
+}
@@ -1,22 +1,57 @@
 package main
 
-import "github.com/spf13/viper"
+import (
+	"github.com/spf13/viper"
+	"time"
+)
 
 type Config struct {
-	Environment    string `mapstructure:"ENV"`
-	Swagger        bool   `mapstructure:"SWAGGER"`
-	Cors           bool   `mapstructure:"CORS"`
-	DBDriver       string `mapstructure:"DB_DRIVER"`
-	KafkaServer    string `mapstructure:"KAFKA_SERVER"`
-	KafkaTopic     string `mapstructure:"KAFKA_TOPIC"`
-	KafkaAcks      string `mapstructure:"KAFKA_ACKS"`
-	DBHost         string `mapstructure:"DB_HOST"`
-	DBPort         int    `mapstructure:"DB_PORT"`
-	DBName         string `mapstructure:"DB_NAME"`
-	DBUsername     string `mapstructure:"DB_USERNAME"`
-	DBPassword     string `mapstructure:"DB_PASSWORD"`
-	HTTPPort       int    `mapstructure:"HTTP_PORT"`
-	TokenSignature string `mapstructure:"JWT_TOKEN_SIGNATURE"`
+	Environment       string        `mapstructure:"ENV"`
+	Swagger           bool          `mapstructure:"SWAGGER"`
+	Cors              bool          `mapstructure:"CORS"`
+	Metrics           bool          `mapstructure:"METRICS"`
+	DBDriver          string        `mapstructure:"DB_DRIVER"`
+	KafkaServer       string        `mapstructure:"KAFKA_SERVER"`
+	KafkaTopic        string        `mapstructure:"KAFKA_TOPIC"`
+	KafkaAcks         string        `mapstructure:"KAFKA_ACKS"`
+	KafkaRetries      int           `mapstructure:"KAFKA_MAX_RETRIES"`
+	KafkaBackoff      time.Duration `mapstructure:"KAFKA_RETRY_BACKOFF"`
+	KafkaTimeout      time.Duration `mapstructure:"KAFKA_DELIVERY_TIMEOUT"`
+	DBHost            string        `mapstructure:"DB_HOST"`
+	DBPort            int           `mapstructure:"DB_PORT"`
+	DBName            string        `mapstructure:"DB_NAME"`
+	DBUsername        string        `mapstructure:"DB_USERNAME"`
+	DBPassword        string        `mapstructure:"DB_PASSWORD"`
+	HTTPPort          int           `mapstructure:"HTTP_PORT"`
+	RequestTimeout    time.Duration `mapstructure:"HTTP_REQUEST_TIMEOUT"`
+	DrainDelay        time.Duration `mapstructure:"HTTP_DRAIN_DELAY"`
+	QueryTimeout      time.Duration `mapstructure:"DB_QUERY_TIMEOUT"`
+	MigrateOnStart    bool          `mapstructure:"DB_MIGRATE_ON_START"`
+	TokenSignature    string        `mapstructure:"JWT_TOKEN_SIGNATURE"`
+	TokenSecrets      []string      `mapstructure:"JWT_TOKEN_SIGNATURES"`
+	PublicKeyFiles    []string      `mapstructure:"JWT_PUBLIC_KEY_FILES"`
+	JWKSFile          string        `mapstructure:"JWT_JWKS_FILE"`
+	JWKSURL           string        `mapstructure:"JWT_JWKS_URL"`
+	JWKSRefresh       time.Duration `mapstructure:"JWT_JWKS_REFRESH"`
+	TokenIssuer       string        `mapstructure:"JWT_TOKEN_ISSUER"`
+	TokenAudience     string        `mapstructure:"JWT_TOKEN_AUDIENCE"`
+	LegacyTokenHeader bool          `mapstructure:"JWT_LEGACY_TOKEN_HEADER"`
+	IdempotencyTTL    time.Duration `mapstructure:"IDEMPOTENCY_TTL"`
+	BatchSummaryEvent bool          `mapstructure:"BATCH_SUMMARY_EVENT"`
+	OutboxInterval    time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL"`
+	OutboxBatch       int           `mapstructure:"OUTBOX_BATCH_SIZE"`
+	OutboxClaimTTL    time.Duration `mapstructure:"OUTBOX_CLAIM_TTL"`
+	OutboxRetention   time.Duration `mapstructure:"OUTBOX_RETENTION"`
+	PurgeRetention    time.Duration `mapstructure:"PURGE_RETENTION"`
+	PurgeInterval     time.Duration `mapstructure:"PURGE_INTERVAL"`
+	PurgeBatch        int           `mapstructure:"PURGE_BATCH_SIZE"`
+	EventSink         string        `mapstructure:"EVENT_SINK"`
+	EventSinkFile     string        `mapstructure:"EVENT_SINK_FILE"`
+	TraceExporter     string        `mapstructure:"TRACING_EXPORTER"`
+	TraceEndpoint     string        `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	TraceInsecure     bool          `mapstructure:"TRACING_OTLP_INSECURE"`
+	TraceFile         string        `mapstructure:"TRACING_FILE"`
+	TraceRatio        float64       `mapstructure:"TRACING_SAMPLE_RATIO"`
 }
 
 func LoadConfig(path string) (Config, error) {
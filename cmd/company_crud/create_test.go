@@ -6,6 +6,7 @@ import (
 	"company-crud/internal/repositories/db"
 	"company-crud/pkg/logger"
 	pkgPg "company-crud/pkg/postres"
+	"context"
 	"encoding/json"
 	"github.com/stretchr/testify/require"
 	"net/http"
@@ -15,11 +16,12 @@ import (
 func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger) {
 	t.Run("Valid creation - with token", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testName_1",
 			Description:     "description_1",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -27,25 +29,26 @@ func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 		require.Equal(t, req.Name, companyDBData.Name)
 		require.Equal(t, &req.Description, companyDBData.Description)
 		require.Equal(t, req.EmployeesNumber, companyDBData.EmployeesNumber)
-		require.Equal(t, &req.IsRegistered, companyDBData.IsRegistered)
+		require.Equal(t, req.IsRegistered, companyDBData.IsRegistered)
 		require.Equal(t, req.Type, companyDBData.Type.String())
 	})
 
 	t.Run("Valid creation but duplicate - with token", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testName_1",
 			Description:     "description_1",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -58,11 +61,12 @@ func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 
 	t.Run("Valid creation - without token", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testName_2",
 			Description:     "description_2",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -70,21 +74,22 @@ func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, "", "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusForbidden, status)
+		require.Equal(t, http.StatusUnauthorized, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.ErrorIs(t, err, pkgPg.NoRowsErr)
 		require.Equal(t, companyDBData, domain.Company{})
 	})
 
 	t.Run("Invalid company type for creation", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testName_3",
 			Description:     "description_3",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "invalidType",
 		}
 
@@ -92,20 +97,21 @@ func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusNotAcceptable, status)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.ErrorIs(t, err, pkgPg.NoRowsErr)
 		require.Equal(t, companyDBData, domain.Company{})
 	})
 
 	t.Run("Invalid Creation with missing name", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Description:     "description_4",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "invalidType",
 		}
 
@@ -113,15 +119,16 @@ func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusNotAcceptable, status)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
 	})
 
 	t.Run("Valid Creation with missing description", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testName_5",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -129,22 +136,23 @@ func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 		require.Equal(t, req.Name, companyDBData.Name)
 		require.Equal(t, &req.Description, companyDBData.Description)
 		require.Equal(t, req.EmployeesNumber, companyDBData.EmployeesNumber)
-		require.Equal(t, &req.IsRegistered, companyDBData.IsRegistered)
+		require.Equal(t, req.IsRegistered, companyDBData.IsRegistered)
 		require.Equal(t, req.Type, companyDBData.Type.String())
 	})
 
 	t.Run("Invalid Creation with missing employeesNumber", func(t *testing.T) {
+		registered := true
 		req := jsons.Create{
 			Name:         "testName_6",
-			IsRegistered: true,
+			IsRegistered: &registered,
 			Type:         "NonProfit",
 		}
 
@@ -152,10 +160,10 @@ func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusNotAcceptable, status)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.ErrorIs(t, pkgPg.NoRowsErr, err)
 		require.Equal(t, domain.Company{}, companyDBData)
 	})
@@ -173,42 +181,44 @@ func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, "", "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusForbidden, status)
+		require.Equal(t, http.StatusUnauthorized, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.ErrorIs(t, err, pkgPg.NoRowsErr)
 		require.Equal(t, companyDBData, domain.Company{})
 	})
 
 	t.Run("Invalid Creation with missing type", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testName_8",
 			Description:     "description_8",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 		}
 
 		jsonData, err := json.Marshal(req)
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, "", "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusForbidden, status)
+		require.Equal(t, http.StatusUnauthorized, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.ErrorIs(t, err, pkgPg.NoRowsErr)
 		require.Equal(t, companyDBData, domain.Company{})
 	})
 
 	t.Run("Valid creation with Corporations type", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testName_9",
 			Description:     "description_9",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "Corporations",
 		}
 
@@ -216,25 +226,26 @@ func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 		require.Equal(t, req.Name, companyDBData.Name)
 		require.Equal(t, &req.Description, companyDBData.Description)
 		require.Equal(t, req.EmployeesNumber, companyDBData.EmployeesNumber)
-		require.Equal(t, &req.IsRegistered, companyDBData.IsRegistered)
+		require.Equal(t, req.IsRegistered, companyDBData.IsRegistered)
 		require.Equal(t, req.Type, companyDBData.Type.String())
 	})
 
 	t.Run("Valid creation with Cooperative type", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testName_10",
 			Description:     "description_10",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "Cooperative",
 		}
 
@@ -242,25 +253,26 @@ func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 		require.Equal(t, req.Name, companyDBData.Name)
 		require.Equal(t, &req.Description, companyDBData.Description)
 		require.Equal(t, req.EmployeesNumber, companyDBData.EmployeesNumber)
-		require.Equal(t, &req.IsRegistered, companyDBData.IsRegistered)
+		require.Equal(t, req.IsRegistered, companyDBData.IsRegistered)
 		require.Equal(t, req.Type, companyDBData.Type.String())
 	})
 
 	t.Run("Valid creation with Sole Proprietorship type", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testName_11",
 			Description:     "description_11",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "Sole Proprietorship",
 		}
 
@@ -268,25 +280,26 @@ func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 		require.Equal(t, req.Name, companyDBData.Name)
 		require.Equal(t, &req.Description, companyDBData.Description)
 		require.Equal(t, req.EmployeesNumber, companyDBData.EmployeesNumber)
-		require.Equal(t, &req.IsRegistered, companyDBData.IsRegistered)
+		require.Equal(t, req.IsRegistered, companyDBData.IsRegistered)
 		require.Equal(t, req.Type, companyDBData.Type.String())
 	})
 
 	t.Run("Valid creation with employeesNumber 0", func(t *testing.T) {
 		employees := 0
+		registered := true
 		req := jsons.Create{
 			Name:            "testName_12",
 			Description:     "description_12",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "Sole Proprietorship",
 		}
 
@@ -294,15 +307,51 @@ func (s *Suite) testCreateHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 		require.Equal(t, req.Name, companyDBData.Name)
 		require.Equal(t, &req.Description, companyDBData.Description)
 		require.Equal(t, req.EmployeesNumber, companyDBData.EmployeesNumber)
-		require.Equal(t, &req.IsRegistered, companyDBData.IsRegistered)
+		require.Equal(t, req.IsRegistered, companyDBData.IsRegistered)
 		require.Equal(t, req.Type, companyDBData.Type.String())
 	})
+
+	t.Run("Invalid creation reports field violations", func(t *testing.T) {
+		employees := 2
+		registered := true
+		req := jsons.Create{
+			Name:            "testName_with_a_long_name",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		}
+
+		jsonData, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		resp, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+
+		problem := jsons.Problem{}
+		err = json.Unmarshal(resp, &problem)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnprocessableEntity, problem.Status)
+		require.Equal(t, "/problems/validation", problem.Type)
+		require.Len(t, problem.Violations, 1)
+		require.Equal(t, "name", problem.Violations[0].Field)
+		require.Equal(t, "max", problem.Violations[0].Rule)
+	})
+
+	t.Run("Creation with malformed body", func(t *testing.T) {
+		resp, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", []byte("{"))
+		require.Equal(t, http.StatusBadRequest, status)
+
+		problem := jsons.Problem{}
+		err := json.Unmarshal(resp, &problem)
+		require.NoError(t, err)
+		require.Equal(t, "/problems/malformed-body", problem.Type)
+	})
 }
@@ -5,6 +5,7 @@ import (
 	"company-crud/internal/repositories/db"
 	"company-crud/pkg/logger"
 	pkgPg "company-crud/pkg/postres"
+	"context"
 	"encoding/json"
 	"github.com/stretchr/testify/require"
 	"net/http"
@@ -14,11 +15,12 @@ import (
 func (s *Suite) testDeleteHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger) {
 	t.Run("Valid delete - with token", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testNameDel_1",
 			Description:     "description_1",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -26,30 +28,31 @@ func (s *Suite) testDeleteHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 
 		_, status = s.testClientDelete(t, s.token, "http://localhost:8000/companies/"+companyDBData.Name)
 		require.Equal(t, http.StatusOK, status)
 
-		companyDBData, err = companyDB.GetByName(req.Name)
+		companyDBData, err = companyDB.GetByName(context.Background(), req.Name)
 		require.ErrorIs(t, err, pkgPg.NoRowsErr)
 
 		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/"+req.Name)
-		require.Equal(t, http.StatusConflict, status)
+		require.Equal(t, http.StatusNotFound, status)
 
 	})
 
 	t.Run("Valid delete - without token", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testNameDel_2",
 			Description:     "description_1",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -57,16 +60,16 @@ func (s *Suite) testDeleteHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 
 		_, status = s.testClientDelete(t, "", "http://localhost:8000/companies/"+companyDBData.Name)
-		require.Equal(t, http.StatusForbidden, status)
+		require.Equal(t, http.StatusUnauthorized, status)
 
-		companyDBData, err = companyDB.GetByName(req.Name)
+		companyDBData, err = companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 
 		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/"+companyDBData.Name)
@@ -75,6 +78,6 @@ func (s *Suite) testDeleteHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logge
 
 	t.Run("Delete invalid delete", func(t *testing.T) {
 		_, status := s.testClientDelete(t, s.token, "http://localhost:8000/companies/"+"randomName")
-		require.Equal(t, http.StatusConflict, status)
+		require.Equal(t, http.StatusNotFound, status)
 	})
 }
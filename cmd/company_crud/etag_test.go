@@ -0,0 +1,68 @@
+package main
+
+import (
+	jsons "company-crud/internal/handlers/http"
+	"company-crud/internal/repositories/db"
+	"company-crud/pkg/logger"
+	pkgPg "company-crud/pkg/postres"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func (s *Suite) testETagHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger) {
+	t.Run("Conditional get and patch - with token", func(t *testing.T) {
+		employees := 2
+		registered := true
+		req := jsons.Create{
+			Name:            "testNameETag_1",
+			Description:     "description_1",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		}
+
+		jsonData, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+		require.Equal(t, http.StatusCreated, status)
+
+		_, headers, status := s.testClientRequest(t, http.MethodGet, s.token, "http://localhost:8000/companies/"+req.Name, nil, nil)
+		require.Equal(t, http.StatusOK, status)
+		tag := headers.Get("ETag")
+		require.NotEmpty(t, tag)
+
+		_, _, status = s.testClientRequest(t, http.MethodGet, s.token, "http://localhost:8000/companies/"+req.Name, map[string]string{"If-None-Match": tag}, nil)
+		require.Equal(t, http.StatusNotModified, status)
+
+		desc := "test_patched_desc_1"
+		jsonData, err = json.Marshal(jsons.Patch{Description: &desc})
+		require.NoError(t, err)
+
+		_, _, status = s.testClientRequest(t, http.MethodPatch, s.token, "http://localhost:8000/companies/"+req.Name, map[string]string{"If-Match": tag}, jsonData)
+		require.Equal(t, http.StatusOK, status)
+
+		companyDB := db.New(pg, log)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
+		require.NoError(t, err)
+		require.Equal(t, &desc, companyDBData.Description)
+		require.Equal(t, 2, companyDBData.Version)
+
+		// The first patch moved the company to a new revision, so the old tag is stale.
+		_, _, status = s.testClientRequest(t, http.MethodPatch, s.token, "http://localhost:8000/companies/"+req.Name, map[string]string{"If-Match": tag}, jsonData)
+		require.Equal(t, http.StatusPreconditionFailed, status)
+
+		_, _, status = s.testClientRequest(t, http.MethodDelete, s.token, "http://localhost:8000/companies/"+req.Name, map[string]string{"If-Match": tag}, nil)
+		require.Equal(t, http.StatusPreconditionFailed, status)
+
+		_, headers, status = s.testClientRequest(t, http.MethodGet, s.token, "http://localhost:8000/companies/"+req.Name, nil, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.NotEqual(t, tag, headers.Get("ETag"))
+
+		_, _, status = s.testClientRequest(t, http.MethodDelete, s.token, "http://localhost:8000/companies/"+req.Name, map[string]string{"If-Match": headers.Get("ETag")}, nil)
+		require.Equal(t, http.StatusOK, status)
+	})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	jsons "company-crud/internal/handlers/http"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func (s *Suite) testExportHttpCases(t *testing.T) {
+	since := time.Now().UTC()
+
+	// More companies than fetched from the cursor at once.
+	var file strings.Builder
+	for i := 0; i < 600; i++ {
+		fmt.Fprintf(&file, `{"name":"testExpB_%04d","description":"bulk","amount_of_employees":%d,"registered":true,"type":"Cooperative"}`+"\n", i, i)
+	}
+	_, _, status := s.testClientRequest(t, http.MethodPost, s.token, "http://localhost:8000/companies/import",
+		map[string]string{"Content-Type": "application/x-ndjson"}, []byte(file.String()))
+	require.Equal(t, http.StatusOK, status)
+
+	employees := 1000
+	registered := true
+	jsonData, err := json.Marshal(jsons.Create{
+		Name:            "testExpSole",
+		Description:     "sole, with a comma",
+		EmployeesNumber: &employees,
+		IsRegistered:    &registered,
+		Type:            "Sole Proprietorship",
+	})
+	require.NoError(t, err)
+	_, status = s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+	require.Equal(t, http.StatusCreated, status)
+
+	exportURL := func(params url.Values) string {
+		params.Set("created_after", since.Format(time.RFC3339Nano))
+		return "http://localhost:8000/companies/export?" + params.Encode()
+	}
+
+	t.Run("Companies are exported as JSON - with token", func(t *testing.T) {
+		resp, headers, status := s.testClientRequest(t, http.MethodGet, s.token, exportURL(url.Values{}), nil, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "application/json", headers.Get("Content-Type"))
+		require.Contains(t, headers.Get("Content-Disposition"), `filename="companies.json"`)
+
+		var companies []jsons.Get
+		require.NoError(t, json.Unmarshal(resp, &companies))
+		require.Len(t, companies, 601)
+		for i, company := range companies[:600] {
+			require.Equal(t, fmt.Sprintf("testExpB_%04d", i), company.Name)
+			require.Equal(t, i, company.EmployeesNumber)
+		}
+		require.Equal(t, "testExpSole", companies[600].Name)
+		require.Equal(t, "Sole Proprietorship", companies[600].Type)
+	})
+
+	t.Run("Companies are exported as NDJSON with the selected columns - with token", func(t *testing.T) {
+		resp, headers, status := s.testClientRequest(t, http.MethodGet, s.token,
+			exportURL(url.Values{"format": {"ndjson"}, "columns": {"name,type"}, "type": {"Sole Proprietorship"}}), nil, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "application/x-ndjson", headers.Get("Content-Type"))
+		require.Equal(t, `{"name":"testExpSole","type":"Sole Proprietorship"}`+"\n", string(resp))
+	})
+
+	t.Run("Companies are exported as gzipped CSV - with token", func(t *testing.T) {
+		resp, headers, status := s.testClientRequest(t, http.MethodGet, s.token,
+			exportURL(url.Values{"format": {"csv"}, "min_employees": {"590"}, "order": {"desc"}}),
+			map[string]string{"Accept-Encoding": "gzip"}, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "text/csv", headers.Get("Content-Type"))
+		require.Equal(t, "gzip", headers.Get("Content-Encoding"))
+
+		reader, err := gzip.NewReader(bytes.NewReader(resp))
+		require.NoError(t, err)
+		records, err := csv.NewReader(reader).ReadAll()
+		require.NoError(t, err)
+
+		require.Len(t, records, 12)
+		require.Equal(t, []string{"id", "name", "description", "amount_of_employees", "registered", "type", "created_at", "updated_at", "version"}, records[0])
+		require.Equal(t, "testExpSole", records[1][1])
+		require.Equal(t, "sole, with a comma", records[1][2])
+		require.Equal(t, "testExpB_0599", records[2][1])
+		require.Equal(t, "testExpB_0590", records[11][1])
+		require.Equal(t, "true", records[11][4])
+	})
+
+	t.Run("Empty exports are still well formed - with token", func(t *testing.T) {
+		resp, _, status := s.testClientRequest(t, http.MethodGet, s.token, exportURL(url.Values{"min_employees": {"100000"}}), nil, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "[]", string(resp))
+
+		resp, _, status = s.testClientRequest(t, http.MethodGet, s.token,
+			exportURL(url.Values{"format": {"csv"}, "columns": {"id"}, "min_employees": {"100000"}}), nil, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "id\n", string(resp))
+	})
+
+	t.Run("Invalid exports are rejected - with token", func(t *testing.T) {
+		_, status := s.testClientGet(t, s.token, exportURL(url.Values{"format": {"xml"}}))
+		require.Equal(t, http.StatusBadRequest, status)
+
+		_, status = s.testClientGet(t, s.token, exportURL(url.Values{"columns": {"name,color"}}))
+		require.Equal(t, http.StatusBadRequest, status)
+
+		_, status = s.testClientGet(t, s.token, exportURL(url.Values{"sort": {"employees"}}))
+		require.Equal(t, http.StatusBadRequest, status)
+
+		_, status = s.testClientGet(t, s.signToken(t, "companies:read"), exportURL(url.Values{"include_deleted": {"true"}}))
+		require.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Export name is reserved - with token", func(t *testing.T) {
+		employees := 1
+		registered := true
+		jsonData, err := json.Marshal(jsons.Create{
+			Name:            "export",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "Cooperative",
+		})
+		require.NoError(t, err)
+
+		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+	})
+
+	t.Run("Export - without token", func(t *testing.T) {
+		_, status := s.testClientGet(t, "", exportURL(url.Values{}))
+		require.Equal(t, http.StatusUnauthorized, status)
+	})
+}
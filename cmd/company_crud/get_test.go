@@ -5,6 +5,7 @@ import (
 	"company-crud/internal/repositories/db"
 	"company-crud/pkg/logger"
 	pkgPg "company-crud/pkg/postres"
+	"context"
 	"encoding/json"
 	"github.com/stretchr/testify/require"
 	"net/http"
@@ -14,11 +15,12 @@ import (
 func (s *Suite) testGetHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger) {
 	t.Run("Valid get - with token", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testNameGet_1",
 			Description:     "description_1",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -26,10 +28,10 @@ func (s *Suite) testGetHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.L
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 
 		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/"+companyDBData.Name)
@@ -42,18 +44,19 @@ func (s *Suite) testGetHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.L
 		require.Equal(t, req.Name, getResp.Name)
 		require.Equal(t, req.Description, getResp.Description)
 		require.Equal(t, req.EmployeesNumber, &getResp.EmployeesNumber)
-		require.Equal(t, req.IsRegistered, getResp.IsRegistered)
+		require.Equal(t, *req.IsRegistered, getResp.IsRegistered)
 		require.Equal(t, req.Type, getResp.Type)
 
 	})
 
 	t.Run("Valid get - without token", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testNameGet_2",
 			Description:     "description_2",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -61,23 +64,24 @@ func (s *Suite) testGetHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.L
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 
 		_, status = s.testClientGet(t, "", "http://localhost:8000/companies/"+companyDBData.Name)
-		require.Equal(t, http.StatusForbidden, status)
+		require.Equal(t, http.StatusUnauthorized, status)
 
 	})
 
 	t.Run("Valid get from entry with no description", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testNameGet_3",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -85,10 +89,10 @@ func (s *Suite) testGetHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.L
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 
 		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/"+companyDBData.Name)
@@ -101,17 +105,18 @@ func (s *Suite) testGetHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.L
 		require.Equal(t, req.Name, getResp.Name)
 		require.Equal(t, req.Description, getResp.Description)
 		require.Equal(t, req.EmployeesNumber, &getResp.EmployeesNumber)
-		require.Equal(t, req.IsRegistered, getResp.IsRegistered)
+		require.Equal(t, *req.IsRegistered, getResp.IsRegistered)
 		require.Equal(t, req.Type, getResp.Type)
 
 	})
 
 	t.Run("Valid get from entry with 0 employees number", func(t *testing.T) {
 		employees := 0
+		registered := true
 		req := jsons.Create{
 			Name:            "testNameGet_4",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -119,10 +124,10 @@ func (s *Suite) testGetHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.L
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 
 		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/"+companyDBData.Name)
@@ -135,12 +140,12 @@ func (s *Suite) testGetHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.L
 		require.Equal(t, req.Name, getResp.Name)
 		require.Equal(t, req.Description, getResp.Description)
 		require.Equal(t, req.EmployeesNumber, &getResp.EmployeesNumber)
-		require.Equal(t, req.IsRegistered, getResp.IsRegistered)
+		require.Equal(t, *req.IsRegistered, getResp.IsRegistered)
 		require.Equal(t, req.Type, getResp.Type)
 	})
 
 	t.Run("Get invalid name", func(t *testing.T) {
 		_, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/"+"randomName")
-		require.Equal(t, http.StatusConflict, status)
+		require.Equal(t, http.StatusNotFound, status)
 	})
 }
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"company-crud/pkg/http_server"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func (s *Suite) testHealthHttpCases(t *testing.T) {
+	t.Run("Liveness without token", func(t *testing.T) {
+		_, status := s.testClientGet(t, "", "http://localhost:8000/healthz")
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("Readiness reports every dependency", func(t *testing.T) {
+		resp, err := http.Get("http://localhost:8000/readyz")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		health := http_server.Health{}
+		err = json.Unmarshal(body, &health)
+		require.NoError(t, err)
+		require.Equal(t, "ok", health.Status)
+		require.Equal(t, "ok", health.Checks["postgres"].Status)
+	})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	jsons "company-crud/internal/handlers/http"
+	"company-crud/internal/repositories/db"
+	"company-crud/pkg/logger"
+	pkgPg "company-crud/pkg/postres"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func (s *Suite) testIDHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger) {
+	t.Run("Valid get, patch and delete by id - with token", func(t *testing.T) {
+		employees := 2
+		registered := true
+		req := jsons.Create{
+			Name:            "testNameID_1",
+			Description:     "description_1",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		}
+
+		jsonData, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		resp, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+		require.Equal(t, http.StatusCreated, status)
+
+		created := jsons.Created{}
+		err = json.Unmarshal(resp, &created)
+		require.NoError(t, err)
+
+		companyDB := db.New(pg, log)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
+		require.NoError(t, err)
+		require.Equal(t, companyDBData.ID, created.ID)
+
+		resp, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/id/"+created.ID.String())
+		require.Equal(t, http.StatusOK, status)
+
+		getResp := jsons.Get{}
+		err = json.Unmarshal(resp, &getResp)
+		require.NoError(t, err)
+		require.Equal(t, created.ID, getResp.ID)
+		require.Equal(t, req.Name, getResp.Name)
+
+		patchReq := jsons.Patch{
+			Name: "testNameID_2",
+		}
+
+		jsonData, err = json.Marshal(patchReq)
+		require.NoError(t, err)
+
+		_, status = s.testClientPatch(t, s.token, "http://localhost:8000/companies/id/"+created.ID.String(), jsonData)
+		require.Equal(t, http.StatusOK, status)
+
+		companyDBData, err = companyDB.GetByID(context.Background(), created.ID)
+		require.NoError(t, err)
+		require.Equal(t, patchReq.Name, companyDBData.Name)
+
+		_, status = s.testClientDelete(t, s.token, "http://localhost:8000/companies/id/"+created.ID.String())
+		require.Equal(t, http.StatusOK, status)
+
+		_, err = companyDB.GetByID(context.Background(), created.ID)
+		require.ErrorIs(t, err, pkgPg.NoRowsErr)
+	})
+
+	t.Run("Get by id - without token", func(t *testing.T) {
+		_, status := s.testClientGet(t, "", "http://localhost:8000/companies/id/9d1bd1a5-3a4e-4f2c-9a38-1f7e3bb2a4b1")
+		require.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("Get by invalid id", func(t *testing.T) {
+		_, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/id/randomID")
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Delete by unknown id", func(t *testing.T) {
+		_, status := s.testClientDelete(t, s.token, "http://localhost:8000/companies/id/9d1bd1a5-3a4e-4f2c-9a38-1f7e3bb2a4b1")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
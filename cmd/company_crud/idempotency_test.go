@@ -0,0 +1,103 @@
+package main
+
+import (
+	jsons "company-crud/internal/handlers/http"
+	"company-crud/pkg/logger"
+	pkgPg "company-crud/pkg/postres"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func (s *Suite) testIdempotencyHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger) {
+	t.Run("Retried creation with the same key - with token", func(t *testing.T) {
+		employees := 2
+		registered := true
+		req := jsons.Create{
+			Name:            "testNameIdem_1",
+			Description:     "description_1",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		}
+
+		jsonData, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		headers := map[string]string{"Idempotency-Key": "testIdempotencyKey_1"}
+
+		resp, respHeaders, status := s.testClientRequest(t, http.MethodPost, s.token, "http://localhost:8000/companies", headers, jsonData)
+		require.Equal(t, http.StatusCreated, status)
+
+		created := jsons.Created{}
+		err = json.Unmarshal(resp, &created)
+		require.NoError(t, err)
+
+		resp, replayHeaders, status := s.testClientRequest(t, http.MethodPost, s.token, "http://localhost:8000/companies", headers, jsonData)
+		require.Equal(t, http.StatusCreated, status)
+		require.Equal(t, "true", replayHeaders.Get("Idempotent-Replayed"))
+		require.Equal(t, respHeaders.Get("Location"), replayHeaders.Get("Location"))
+
+		replayed := jsons.Created{}
+		err = json.Unmarshal(resp, &replayed)
+		require.NoError(t, err)
+		require.Equal(t, created.ID, replayed.ID)
+	})
+
+	t.Run("Reused key with a different body - with token", func(t *testing.T) {
+		employees := 3
+		registered := true
+		req := jsons.Create{
+			Name:            "testNameIdem_2",
+			Description:     "description_2",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		}
+
+		jsonData, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		_, _, status := s.testClientRequest(t, http.MethodPost, s.token, "http://localhost:8000/companies", map[string]string{"Idempotency-Key": "testIdempotencyKey_1"}, jsonData)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+	})
+
+	t.Run("Same key sent by another caller - with token", func(t *testing.T) {
+		employees := 2
+		registered := true
+		jsonData, err := json.Marshal(jsons.Create{
+			Name:            "testNameIdem_1",
+			Description:     "description_1",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		})
+		require.NoError(t, err)
+
+		// The request is processed again instead of replaying the response of the first caller.
+		otherToken := s.signTokenWithClaims(t, jwt.MapClaims{
+			"sub":   "another-caller",
+			"iss":   s.tokenCfg.TokenIssuer,
+			"aud":   s.tokenCfg.TokenAudience,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": "companies:write",
+		})
+		_, headers, status := s.testClientRequest(t, http.MethodPost, otherToken, "http://localhost:8000/companies", map[string]string{"Idempotency-Key": "testIdempotencyKey_1"}, jsonData)
+		require.Equal(t, http.StatusConflict, status)
+		require.Empty(t, headers.Get("Idempotent-Replayed"))
+	})
+
+	t.Run("Key sent without a token subject", func(t *testing.T) {
+		noSubject := s.signTokenWithClaims(t, jwt.MapClaims{
+			"iss":   s.tokenCfg.TokenIssuer,
+			"aud":   s.tokenCfg.TokenAudience,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": "companies:write",
+		})
+		_, _, status := s.testClientRequest(t, http.MethodPost, noSubject, "http://localhost:8000/companies", map[string]string{"Idempotency-Key": "testIdempotencyKey_2"}, []byte(`{}`))
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+}
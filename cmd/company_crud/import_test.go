@@ -0,0 +1,244 @@
+package main
+
+import (
+	jsons "company-crud/internal/handlers/http"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func (s *Suite) testImportHttpCases(t *testing.T) {
+	importFile := func(t *testing.T, token, query, contentType, file string) (jsons.ImportReport, int) {
+		resp, _, status := s.testClientRequest(t, http.MethodPost, token, "http://localhost:8000/companies/import"+query,
+			map[string]string{"Content-Type": contentType}, []byte(file))
+
+		report := jsons.ImportReport{}
+		if status == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp, &report))
+		}
+
+		return report, status
+	}
+
+	getCompany := func(t *testing.T, name string) (jsons.Get, int) {
+		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/"+name)
+
+		company := jsons.Get{}
+		if status == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp, &company))
+		}
+
+		return company, status
+	}
+
+	t.Run("CSV rows are imported - with token", func(t *testing.T) {
+		report, status := importFile(t, s.token, "", "text/csv",
+			"\ufeffname,description,amount_of_employees,registered,type\n"+
+				"testNameImp_1,first,10,true,Corporations\n"+
+				"testNameImp_2,\"second, quoted\",20,true,NonProfit\n"+
+				"testNameImp_3,third,30,true,Unknown\n"+
+				"testNameImp_4,fourth,many,true,Cooperative\n"+
+				"testNameImp_1,again,10,true,Corporations\n")
+		require.Equal(t, http.StatusOK, status)
+		require.False(t, report.DryRun)
+		require.Equal(t, 5, report.Rows)
+		require.Equal(t, 2, report.Created)
+		require.Zero(t, report.Updated)
+		require.Equal(t, 3, report.Failed)
+
+		require.Len(t, report.Errors, 3)
+		expected := []struct {
+			line   int
+			name   string
+			status int
+		}{
+			{4, "testNameImp_3", http.StatusUnprocessableEntity},
+			{5, "testNameImp_4", http.StatusUnprocessableEntity},
+			{6, "testNameImp_1", http.StatusConflict},
+		}
+		for i, importErr := range report.Errors {
+			require.Equal(t, expected[i].line, importErr.Line)
+			require.Equal(t, expected[i].name, importErr.Name)
+			require.Equal(t, expected[i].status, importErr.Error.Status)
+		}
+
+		company, status := getCompany(t, "testNameImp_2")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "second, quoted", company.Description)
+		require.Equal(t, 20, company.EmployeesNumber)
+	})
+
+	t.Run("Unregistered companies are imported - with token", func(t *testing.T) {
+		report, status := importFile(t, s.token, "", "text/csv",
+			"name,amount_of_employees,registered,type\n"+
+				"testNameImp_10,1,false,Cooperative\n")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 1, report.Created)
+		require.Zero(t, report.Failed)
+
+		report, status = importFile(t, s.token, "", "application/x-ndjson",
+			`{"name":"testNameImp_11","amount_of_employees":1,"registered":false,"type":"Cooperative"}`+"\n")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 1, report.Created)
+		require.Zero(t, report.Failed)
+
+		for _, name := range []string{"testNameImp_10", "testNameImp_11"} {
+			company, status := getCompany(t, name)
+			require.Equal(t, http.StatusOK, status)
+			require.False(t, company.IsRegistered)
+		}
+	})
+
+	t.Run("Dry run saves nothing - with token", func(t *testing.T) {
+		report, status := importFile(t, s.token, "?dry_run=true", "application/x-ndjson",
+			`{"name":"testNameImp_5","description":"fifth","amount_of_employees":5,"registered":true,"type":"Cooperative"}`+"\n"+
+				"\n"+
+				`{"name":"testNameImp_1","description":"first","amount_of_employees":10,"registered":true,"type":"Corporations"}`+"\n"+
+				`{"name":`+"\n")
+		require.Equal(t, http.StatusOK, status)
+		require.True(t, report.DryRun)
+		require.Equal(t, 3, report.Rows)
+		require.Equal(t, 1, report.Created)
+		require.Equal(t, 2, report.Failed)
+		require.Equal(t, 3, report.Errors[0].Line)
+		require.Equal(t, http.StatusConflict, report.Errors[0].Error.Status)
+		require.Equal(t, 4, report.Errors[1].Line)
+		require.Equal(t, http.StatusBadRequest, report.Errors[1].Error.Status)
+
+		_, status = getCompany(t, "testNameImp_5")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Dry run reports duplicates across chunks - with token", func(t *testing.T) {
+		file := strings.Builder{}
+		file.WriteString("name,amount_of_employees,registered,type\n")
+		for i := 0; i < 500; i++ {
+			fmt.Fprintf(&file, "testImpDry_%04d,1,true,Cooperative\n", i)
+		}
+		// Past the first chunk of 500 rows.
+		file.WriteString("testImpDry_0000,1,true,Cooperative\n")
+
+		report, status := importFile(t, s.token, "?dry_run=true", "text/csv", file.String())
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 501, report.Rows)
+		require.Equal(t, 500, report.Created)
+		require.Equal(t, 1, report.Failed)
+		require.Equal(t, 502, report.Errors[0].Line)
+		require.Equal(t, http.StatusConflict, report.Errors[0].Error.Status)
+
+		report, status = importFile(t, s.token, "?dry_run=true&upsert=true", "text/csv", file.String())
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 500, report.Created)
+		require.Equal(t, 1, report.Updated)
+		require.Zero(t, report.Failed)
+
+		_, status = getCompany(t, "testImpDry_0000")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Upsert updates the existing companies - with token", func(t *testing.T) {
+		before, status := getCompany(t, "testNameImp_1")
+		require.Equal(t, http.StatusOK, status)
+
+		report, status := importFile(t, s.token, "?upsert=true", "application/x-ndjson",
+			`{"name":"testNameImp_1","description":"updated","amount_of_employees":11,"registered":true,"type":"Corporations"}`+"\n"+
+				`{"name":"testNameImp_6","description":"sixth","amount_of_employees":6,"registered":true,"type":"NonProfit"}`+"\n")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 2, report.Rows)
+		require.Equal(t, 1, report.Created)
+		require.Equal(t, 1, report.Updated)
+		require.Zero(t, report.Failed)
+
+		after, status := getCompany(t, "testNameImp_1")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, before.ID, after.ID)
+		require.Equal(t, "updated", after.Description)
+		require.Equal(t, 11, after.EmployeesNumber)
+		require.Equal(t, before.Version+1, after.Version)
+
+		_, status = getCompany(t, "testNameImp_6")
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("Error report is downloadable as CSV - with token", func(t *testing.T) {
+		resp, headers, status := s.testClientRequest(t, http.MethodPost, s.token, "http://localhost:8000/companies/import",
+			map[string]string{"Content-Type": "text/csv; charset=utf-8", "Accept": "text/csv"},
+			[]byte("name,amount_of_employees,registered,type\ntestNameImp_7,,true,Corporations\n"))
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "text/csv", headers.Get("Content-Type"))
+		require.Contains(t, headers.Get("Content-Disposition"), "attachment")
+
+		records, err := csv.NewReader(strings.NewReader(string(resp))).ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, [][]string{
+			{"line", "name", "status", "type", "detail"},
+			{"2", "testNameImp_7", "422", "/problems/validation", records[1][4]},
+		}, records)
+		require.Contains(t, records[1][4], "amount_of_employees")
+	})
+
+	t.Run("Long error reports are truncated - with token", func(t *testing.T) {
+		file := strings.Builder{}
+		file.WriteString("name,amount_of_employees,registered,type\n")
+		for i := 0; i < 150; i++ {
+			fmt.Fprintf(&file, "testImpErr_%04d,1,true,Unknown\n", i)
+		}
+
+		report, status := importFile(t, s.token, "", "text/csv", file.String())
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 150, report.Failed)
+		require.Len(t, report.Errors, 100)
+		require.True(t, report.Truncated)
+		require.Equal(t, 2, report.Errors[0].Line)
+		require.Equal(t, 101, report.Errors[99].Line)
+
+		resp, _, status := s.testClientRequest(t, http.MethodPost, s.token, "http://localhost:8000/companies/import",
+			map[string]string{"Content-Type": "text/csv", "Accept": "text/csv"}, []byte(file.String()))
+		require.Equal(t, http.StatusOK, status)
+
+		records, err := csv.NewReader(strings.NewReader(string(resp))).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 151)
+		require.Equal(t, "151", records[150][0])
+	})
+
+	t.Run("Reserved names are rejected - with token", func(t *testing.T) {
+		report, status := importFile(t, s.token, "", "text/csv",
+			"name,amount_of_employees,registered,type\nimport,1,true,Cooperative\n")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 1, report.Failed)
+		require.Equal(t, http.StatusUnprocessableEntity, report.Errors[0].Error.Status)
+		require.Equal(t, "reserved", report.Errors[0].Error.Violations[0].Rule)
+
+		patchData, err := json.Marshal(jsons.Patch{Name: "import"})
+		require.NoError(t, err)
+		_, status = s.testClientPatch(t, s.token, "http://localhost:8000/companies/testNameImp_2", patchData)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+	})
+
+	t.Run("Invalid files are rejected - with token", func(t *testing.T) {
+		_, status := importFile(t, s.token, "", "application/json", `{"name":"testNameImp_8"}`)
+		require.Equal(t, http.StatusUnsupportedMediaType, status)
+
+		_, status = importFile(t, s.token, "", "text/csv", "name,color\ntestNameImp_8,blue\n")
+		require.Equal(t, http.StatusBadRequest, status)
+
+		_, status = importFile(t, s.token, "", "text/csv", "")
+		require.Equal(t, http.StatusBadRequest, status)
+
+		_, status = importFile(t, s.token, "?dry_run=maybe", "text/csv", "name\n")
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Import - without write scope", func(t *testing.T) {
+		_, status := importFile(t, s.signToken(t, "companies:read"), "", "text/csv", "name\ntestNameImp_9\n")
+		require.Equal(t, http.StatusForbidden, status)
+
+		_, status = importFile(t, "", "", "text/csv", "name\ntestNameImp_9\n")
+		require.Equal(t, http.StatusUnauthorized, status)
+	})
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/pkg/jwtkeys"
+	"company-crud/pkg/logger"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testKeys are the keys, besides JWT_TOKEN_SIGNATURE, the service under test accepts
+// tokens signed with.
+type testKeys struct {
+	rsa      *rsa.PrivateKey
+	ec       *ecdsa.PrivateKey
+	ed       ed25519.PrivateKey
+	pem      *rsa.PrivateKey
+	secret   string
+	jwksFile string
+	pemFile  string
+	pemKeyID string
+	rsaKeyID string
+	ecKeyID  string
+	edKeyID  string
+}
+
+// newTestKeys generates the keys and writes the public ones to a JWKS document and a PEM
+// file under dir.
+func newTestKeys(t *testing.T, dir string) testKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	pemKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keys := testKeys{
+		rsa:      rsaKey,
+		ec:       ecKey,
+		ed:       edKey,
+		pem:      pemKey,
+		secret:   "rotated-secret",
+		jwksFile: filepath.Join(dir, "jwks.json"),
+		pemFile:  filepath.Join(dir, "pem-key.pem"),
+		pemKeyID: "pem-key",
+		rsaKeyID: "rsa-key",
+		ecKeyID:  "ec-key",
+		edKeyID:  "ed-key",
+	}
+
+	encode := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	document, err := json.Marshal(map[string]any{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": keys.rsaKeyID, "use": "sig", "alg": "RS256", "n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": keys.ecKeyID, "crv": "P-256", "x": encode(ecKey.X.FillBytes(make([]byte, 32))), "y": encode(ecKey.Y.FillBytes(make([]byte, 32)))},
+			{"kty": "OKP", "kid": keys.edKeyID, "crv": "Ed25519", "x": encode(edKey.Public().(ed25519.PublicKey))},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keys.jwksFile, document, 0o600))
+
+	der, err := x509.MarshalPKIXPublicKey(&pemKey.PublicKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keys.pemFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	return keys
+}
+
+// signWith issues a token granting read access, signed by key with method.
+func (s *Suite) signWith(t *testing.T, method jwt.SigningMethod, key any, kid string) string {
+	token := jwt.NewWithClaims(method, jwt.MapClaims{
+		"iss":   s.tokenCfg.TokenIssuer,
+		"aud":   s.tokenCfg.TokenAudience,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": domain.ScopeCompaniesRead,
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func (s *Suite) testJWKSHttpCases(t *testing.T) {
+	accepted := []struct {
+		name  string
+		token string
+	}{
+		{"RS256 from JWKS", s.signWith(t, jwt.SigningMethodRS256, s.keys.rsa, s.keys.rsaKeyID)},
+		{"ES256 from JWKS", s.signWith(t, jwt.SigningMethodES256, s.keys.ec, s.keys.ecKeyID)},
+		{"EdDSA from JWKS", s.signWith(t, jwt.SigningMethodEdDSA, s.keys.ed, s.keys.edKeyID)},
+		{"RS256 from PEM", s.signWith(t, jwt.SigningMethodRS256, s.keys.pem, s.keys.pemKeyID)},
+		{"ES256 without kid", s.signWith(t, jwt.SigningMethodES256, s.keys.ec, "")},
+		{"rotated HMAC secret", s.signWith(t, jwt.SigningMethodHS256, []byte(s.keys.secret), "")},
+		{"HMAC with a kid", s.signWith(t, jwt.SigningMethodHS256, []byte(s.tokenCfg.TokenSignature), "hmac-1")},
+	}
+	for _, c := range accepted {
+		t.Run("Accepted "+c.name, func(t *testing.T) {
+			_, status := s.testClientGet(t, c.token, "http://localhost:8000/companies")
+			require.Equal(t, http.StatusOK, status)
+		})
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	rejected := []struct {
+		name  string
+		token string
+	}{
+		{"unknown RSA key", s.signWith(t, jwt.SigningMethodRS256, otherKey, "")},
+		{"unknown kid", s.signWith(t, jwt.SigningMethodRS256, s.keys.rsa, "unknown")},
+		{"kid of another key", s.signWith(t, jwt.SigningMethodRS256, s.keys.rsa, s.keys.pemKeyID)},
+		{"alg other than the JWK's", s.signWith(t, jwt.SigningMethodPS256, s.keys.rsa, s.keys.rsaKeyID)},
+		{"unknown HMAC secret", s.signWith(t, jwt.SigningMethodHS256, []byte("unknown-secret"), "")},
+	}
+	for _, c := range rejected {
+		t.Run("Rejected "+c.name, func(t *testing.T) {
+			_, status := s.testClientGet(t, c.token, "http://localhost:8000/companies")
+			require.Equal(t, http.StatusUnauthorized, status)
+		})
+	}
+
+	t.Run("Symmetric key in JWKS is refused", func(t *testing.T) {
+		jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+		document := `{"keys":[{"kty":"oct","kid":"hmac-jwks","k":"` + base64.RawURLEncoding.EncodeToString([]byte("secret")) + `"}]}`
+		require.NoError(t, os.WriteFile(jwksFile, []byte(document), 0o600))
+
+		_, err := jwtkeys.New(jwtkeys.Config{JWKSFile: jwksFile}, &logger.Logger{Logger: zap.NewNop()})
+		require.Error(t, err)
+	})
+}
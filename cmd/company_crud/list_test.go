@@ -0,0 +1,80 @@
+package main
+
+import (
+	jsons "company-crud/internal/handlers/http"
+	"company-crud/pkg/logger"
+	pkgPg "company-crud/pkg/postres"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func (s *Suite) testListHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger) {
+	t.Run("Valid list with pagination - with token", func(t *testing.T) {
+		employees := 7
+		registered := true
+		for _, name := range []string{"testNameList_1", "testNameList_2", "testNameList_3"} {
+			req := jsons.Create{
+				Name:            name,
+				Description:     "description_1",
+				EmployeesNumber: &employees,
+				IsRegistered:    &registered,
+				Type:            "Cooperative",
+			}
+
+			jsonData, err := json.Marshal(req)
+			require.NoError(t, err)
+
+			_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+			require.Equal(t, http.StatusCreated, status)
+		}
+
+		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies?type=Cooperative&min_employees=7&max_employees=7&limit=2")
+		require.Equal(t, http.StatusOK, status)
+
+		firstPage := jsons.List{}
+		err := json.Unmarshal(resp, &firstPage)
+		require.NoError(t, err)
+		require.Len(t, firstPage.Companies, 2)
+		require.Equal(t, "testNameList_1", firstPage.Companies[0].Name)
+		require.Equal(t, "testNameList_2", firstPage.Companies[1].Name)
+		require.NotEmpty(t, firstPage.NextCursor)
+
+		resp, status = s.testClientGet(t, s.token, "http://localhost:8000/companies?type=Cooperative&min_employees=7&max_employees=7&limit=2&cursor="+firstPage.NextCursor)
+		require.Equal(t, http.StatusOK, status)
+
+		secondPage := jsons.List{}
+		err = json.Unmarshal(resp, &secondPage)
+		require.NoError(t, err)
+		require.Len(t, secondPage.Companies, 1)
+		require.Equal(t, "testNameList_3", secondPage.Companies[0].Name)
+		require.Empty(t, secondPage.NextCursor)
+	})
+
+	t.Run("Valid list descending - with token", func(t *testing.T) {
+		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies?type=Cooperative&min_employees=7&max_employees=7&sort=name&order=desc")
+		require.Equal(t, http.StatusOK, status)
+
+		page := jsons.List{}
+		err := json.Unmarshal(resp, &page)
+		require.NoError(t, err)
+		require.Len(t, page.Companies, 3)
+		require.Equal(t, "testNameList_3", page.Companies[0].Name)
+	})
+
+	t.Run("Valid list - without token", func(t *testing.T) {
+		_, status := s.testClientGet(t, "", "http://localhost:8000/companies")
+		require.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("List with invalid cursor", func(t *testing.T) {
+		_, status := s.testClientGet(t, s.token, "http://localhost:8000/companies?cursor=randomCursor")
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("List with invalid limit", func(t *testing.T) {
+		_, status := s.testClientGet(t, s.token, "http://localhost:8000/companies?limit=1000")
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+}
@@ -3,28 +3,35 @@ package main
 import (
 	_ "company-crud/api" //swagger
 	"company-crud/internal/app"
+	"company-crud/internal/repositories/db/migrations"
 	"company-crud/pkg/http_server"
+	"company-crud/pkg/jwtkeys"
 	"company-crud/pkg/logger"
+	"company-crud/pkg/migrate"
 	"company-crud/pkg/postres"
 	"company-crud/pkg/producer"
+	"company-crud/pkg/tracing"
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 // @title CompanyCrud
 // @version 0.1
-// @description APIs for a company handler - JWT Token without expiration date: eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.PwN9mqs6JDOROs42oqojiJ0iGEzOtLejuVrDPITuxqw
+// @description APIs for a company handler - run `make token` to mint a JWT for local use.
 
 // @contact.name b10z
 
 // @license.name None
 
-// @securityDefinitions.apikey ApiKeyAuth
+// @securityDefinitions.apikey BearerAuth
 // @in header
-// @name Token
+// @name Authorization
+// @description Type "Bearer" followed by a space and the JWT.
 
 // @host localhost:8000
 
@@ -35,33 +42,79 @@ func main() {
 		os.Exit(0)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		if err := runToken(cfg, os.Args[2:]); err != nil {
+			slog.Error("token failed:", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	log, err := logger.New(cfg.Environment)
 	if err != nil {
 		slog.Error("logger init failed:", "error", err)
 		os.Exit(0)
 	}
 
+	tracer, err := tracing.New(context.Background(), tracing.Config{
+		ServiceName:  "company-crud",
+		Exporter:     cfg.TraceExporter,
+		OTLPEndpoint: cfg.TraceEndpoint,
+		OTLPInsecure: cfg.TraceInsecure,
+		File:         cfg.TraceFile,
+		SampleRatio:  cfg.TraceRatio,
+	})
+	if err != nil {
+		slog.Error("tracing init failed:", "error", err)
+		os.Exit(0)
+	}
+
 	postgres, err := postres.New(postres.Config{
-		DBHost:     cfg.DBHost,
-		DBPort:     cfg.DBPort,
-		DBUsername: cfg.DBUsername,
-		DBPassword: cfg.DBPassword,
-		DBName:     cfg.DBName,
+		DBHost:       cfg.DBHost,
+		DBPort:       cfg.DBPort,
+		DBUsername:   cfg.DBUsername,
+		DBPassword:   cfg.DBPassword,
+		DBName:       cfg.DBName,
+		QueryTimeout: cfg.QueryTimeout,
 	})
 	if err != nil {
 		slog.Error("pg init failed:", "error", err)
 		os.Exit(0)
 	}
 
-	httpServer := http_server.New(cfg.Swagger, cfg.Cors)
+	migrator, err := migrate.New(postgres, log, migrations.FS)
+	if err != nil {
+		slog.Error("migrations init failed:", "error", err)
+		os.Exit(0)
+	}
 
-	kafkaProducer, err := producer.New(producer.Config{
-		Server: cfg.KafkaServer,
-		Acks:   cfg.KafkaAcks,
-		Topic:  cfg.KafkaTopic,
-	})
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), migrator, os.Args[2:]); err != nil {
+			slog.Error("migrate failed:", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.MigrateOnStart {
+		if err := migrator.Up(context.Background()); err != nil {
+			slog.Error("migrate on start failed:", "error", err)
+			os.Exit(0)
+		}
+	}
+
+	tokenKeys, err := jwtkeys.New(tokenKeysConfig(cfg), log)
 	if err != nil {
-		slog.Error("kafka producer init failed:", "error", err)
+		slog.Error("token keys init failed:", "error", err)
+		os.Exit(0)
+	}
+	defer tokenKeys.Stop()
+
+	httpServer := http_server.New(log, cfg.Swagger, cfg.Cors, cfg.Metrics)
+
+	eventProducer, err := newProducer(cfg, log)
+	if err != nil {
+		slog.Error("event producer init failed:", "error", err)
 		os.Exit(0)
 	}
 
@@ -69,8 +122,67 @@ func main() {
 	defer stop()
 
 	companyCrud := app.New(ctx, log, app.Config{
-		TokenSignature: cfg.TokenSignature,
-	}, httpServer, postgres, kafkaProducer)
+		TokenKeys:         tokenKeys,
+		TokenIssuer:       cfg.TokenIssuer,
+		TokenAudience:     cfg.TokenAudience,
+		LegacyTokenHeader: cfg.LegacyTokenHeader,
+		IdempotencyTTL:    cfg.IdempotencyTTL,
+		BatchSummaryEvent: cfg.BatchSummaryEvent,
+		RequestTimeout:    cfg.RequestTimeout,
+		DrainDelay:        cfg.DrainDelay,
+		Relay: app.RelayConfig{
+			PollInterval: cfg.OutboxInterval,
+			BatchSize:    cfg.OutboxBatch,
+			ClaimTTL:     cfg.OutboxClaimTTL,
+			Retention:    cfg.OutboxRetention,
+		},
+		Purge: app.PurgeConfig{
+			Retention: cfg.PurgeRetention,
+			Interval:  cfg.PurgeInterval,
+			BatchSize: cfg.PurgeBatch,
+		},
+	}, httpServer, postgres, eventProducer)
 
 	companyCrud.Run()
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracer.Stop(stopCtx); err != nil {
+		slog.Error("tracing shutdown failed:", "error", err)
+	}
+}
+
+// tokenKeysConfig gathers the keys tokens may be signed with. JWT_TOKEN_SIGNATURES lists
+// the HMAC secrets valid along with JWT_TOKEN_SIGNATURE, e.g. while rotating it.
+func tokenKeysConfig(cfg Config) jwtkeys.Config {
+	return jwtkeys.Config{
+		HMACSecrets:     append([]string{cfg.TokenSignature}, cfg.TokenSecrets...),
+		PublicKeyFiles:  cfg.PublicKeyFiles,
+		JWKSFile:        cfg.JWKSFile,
+		JWKSURL:         cfg.JWKSURL,
+		RefreshInterval: cfg.JWKSRefresh,
+	}
+}
+
+// newProducer builds the event sink selected by EVENT_SINK, Kafka by default.
+func newProducer(cfg Config, log *logger.Logger) (producer.Produce, error) {
+	switch cfg.EventSink {
+	case "", "kafka":
+		return producer.New(producer.Config{
+			Server:          cfg.KafkaServer,
+			Acks:            cfg.KafkaAcks,
+			Topic:           cfg.KafkaTopic,
+			MaxRetries:      cfg.KafkaRetries,
+			RetryBackoff:    cfg.KafkaBackoff,
+			DeliveryTimeout: cfg.KafkaTimeout,
+		}, log)
+	case "file":
+		return producer.NewFile(cfg.EventSinkFile)
+	case "memory":
+		return producer.NewMemory(), nil
+	case "log":
+		return producer.NewLog(log), nil
+	default:
+		return nil, fmt.Errorf("invalid EVENT_SINK %q, expected one of kafka, file, memory, log", cfg.EventSink)
+	}
 }
@@ -2,23 +2,31 @@ package main
 
 import (
 	"company-crud/internal/app"
+	"company-crud/internal/domain"
+	"company-crud/internal/repositories/db/migrations"
 	"company-crud/pkg/http_server"
+	"company-crud/pkg/jwtkeys"
 	"company-crud/pkg/logger"
+	"company-crud/pkg/migrate"
 	"company-crud/pkg/postres"
 	"company-crud/pkg/producer"
+	"company-crud/pkg/tracing"
 	"context"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
 )
 
 func TestIntegration_main(t *testing.T) {
-	suite := New(t, time.Second*5, "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.PwN9mqs6JDOROs42oqojiJ0iGEzOtLejuVrDPITuxqw")
+	suite := New(t, time.Second*5)
 
 	p, err := suite.postgresContainer.MappedPort(context.Background(), "5432")
 	require.NoError(t, err)
@@ -36,34 +44,76 @@ func TestIntegration_main(t *testing.T) {
 	cfg, err := LoadConfig("../../")
 	require.NoError(t, err)
 
-	//logger := zap.NewNop()
-	logger, err := logger.New("test")
+	suite.tokenCfg = cfg
+	suite.token = suite.signToken(t, domain.ScopeCompaniesRead, domain.ScopeCompaniesWrite, domain.ScopeCompaniesDelete, domain.ScopeAuditRead)
+
+	// Log lines are observed so that the tests can inspect the access log.
+	core, logs := observer.New(zap.DebugLevel)
+	logger := &logger.Logger{Logger: zap.New(core)}
+	suite.logs = logs
+
+	tracer, err := tracing.New(context.Background(), tracing.Config{
+		ServiceName: "company-crud-test",
+		Exporter:    tracing.ExporterFile,
+		File:        filepath.Join(t.TempDir(), "traces.json"),
+	})
 	require.NoError(t, err)
+	t.Cleanup(func() {
+		tracer.Stop(context.Background())
+	})
 
 	postgresCli, err := postres.New(postres.Config{
-		DBHost:     host,
-		DBPort:     p.Int(),
-		DBUsername: cfg.DBUsername,
-		DBPassword: cfg.DBPassword,
-		DBName:     cfg.DBName,
+		DBHost:       host,
+		DBPort:       p.Int(),
+		DBUsername:   cfg.DBUsername,
+		DBPassword:   cfg.DBPassword,
+		DBName:       cfg.DBName,
+		QueryTimeout: cfg.QueryTimeout,
 	})
 	require.NoError(t, err)
 
-	httpServer := http_server.New(cfg.Swagger, cfg.Cors)
+	migrator, err := migrate.New(postgresCli, logger, migrations.FS)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(context.Background()))
 
-	kafkaProducer, err := producer.New(producer.Config{
-		Server: "localhost:9092",
-		Acks:   cfg.KafkaAcks,
-		Topic:  cfg.KafkaTopic,
-	})
+	suite.keys = newTestKeys(t, t.TempDir())
+	tokenKeys, err := jwtkeys.New(jwtkeys.Config{
+		HMACSecrets:    []string{cfg.TokenSignature, suite.keys.secret},
+		PublicKeyFiles: []string{suite.keys.pemFile},
+		JWKSFile:       suite.keys.jwksFile,
+	}, logger)
 	require.NoError(t, err)
+	t.Cleanup(tokenKeys.Stop)
+
+	httpServer := http_server.New(logger, cfg.Swagger, cfg.Cors, cfg.Metrics)
+
+	// Events are kept in memory so that the tests can inspect what the relay published.
+	suite.producer = producer.NewMemory()
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL, syscall.SIGQUIT)
 	defer stop()
 
 	companyCrud := app.New(ctx, logger, app.Config{
-		TokenSignature: cfg.TokenSignature,
-	}, httpServer, postgresCli, kafkaProducer)
+		TokenKeys:         tokenKeys,
+		TokenIssuer:       cfg.TokenIssuer,
+		TokenAudience:     cfg.TokenAudience,
+		LegacyTokenHeader: cfg.LegacyTokenHeader,
+		IdempotencyTTL:    cfg.IdempotencyTTL,
+		BatchSummaryEvent: cfg.BatchSummaryEvent,
+		RequestTimeout:    cfg.RequestTimeout,
+		DrainDelay:        cfg.DrainDelay,
+		Relay: app.RelayConfig{
+			PollInterval: cfg.OutboxInterval,
+			BatchSize:    cfg.OutboxBatch,
+			ClaimTTL:     cfg.OutboxClaimTTL,
+			Retention:    cfg.OutboxRetention,
+		},
+		Purge: app.PurgeConfig{
+			Retention: cfg.PurgeRetention,
+			Interval:  cfg.PurgeInterval,
+			BatchSize: cfg.PurgeBatch,
+		},
+	}, httpServer, postgresCli, suite.producer)
 
 	go companyCrud.Run()
 
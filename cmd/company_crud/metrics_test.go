@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func (s *Suite) testMetricsHttpCases(t *testing.T) {
+	t.Run("Metrics are labelled by route template", func(t *testing.T) {
+		_, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/"+"metricsName")
+		require.Equal(t, http.StatusNotFound, status)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/"+"metricsPath")
+		require.Equal(t, http.StatusNotFound, status)
+
+		resp, err := http.Get("http://localhost:8000/metrics")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		metrics := string(body)
+
+		require.True(t, strings.Contains(metrics, `http_requests_total{method="GET",route="/companies/{company_name}",status="404"}`))
+		require.True(t, strings.Contains(metrics, `http_requests_total{method="GET",route="unknown",status="404"}`))
+		require.False(t, strings.Contains(metrics, "metricsName"))
+		require.False(t, strings.Contains(metrics, "metricsPath"))
+		require.True(t, strings.Contains(metrics, "http_request_duration_seconds_bucket"))
+		require.True(t, strings.Contains(metrics, "http_requests_in_flight"))
+		require.True(t, strings.Contains(metrics, "go_sql_open_connections"))
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"company-crud/internal/repositories/db/migrations"
+	"company-crud/pkg/logger"
+	"company-crud/pkg/migrate"
+	pkgPg "company-crud/pkg/postres"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func (s *Suite) testMigrationCases(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger) {
+	migrator, err := migrate.New(pg, log, migrations.FS)
+	require.NoError(t, err)
+
+	t.Run("Every migration is applied", func(t *testing.T) {
+		statuses, err := migrator.Status(context.Background())
+		require.NoError(t, err)
+		require.NotEmpty(t, statuses)
+
+		for _, status := range statuses {
+			require.NotNil(t, status.AppliedAt, "migration %d is pending", status.Version)
+		}
+		require.Equal(t, migrator.Latest(), statuses[len(statuses)-1].Version)
+	})
+
+	t.Run("Applying again is a no-op", func(t *testing.T) {
+		require.NoError(t, migrator.Up(context.Background()))
+	})
+
+	t.Run("Unknown version is rejected", func(t *testing.T) {
+		err := migrator.To(context.Background(), migrator.Latest()+1)
+		require.ErrorIs(t, err, migrate.UnknownVersion)
+	})
+
+	t.Run("Rolling back everything and applying again", func(t *testing.T) {
+		require.NoError(t, migrator.To(context.Background(), 0))
+
+		var companiesTable *string
+		err := pg.Get(&companiesTable, `SELECT to_regclass('xm_assessment.companies')::TEXT`)
+		require.NoError(t, err)
+		require.Nil(t, companiesTable)
+
+		require.NoError(t, migrator.Up(context.Background()))
+
+		err = pg.Get(&companiesTable, `SELECT to_regclass('xm_assessment.companies')::TEXT`)
+		require.NoError(t, err)
+		require.NotNil(t, companiesTable)
+
+		var version int
+		err = pg.Get(&version, `SELECT MAX(version) FROM public.schema_migrations`)
+		require.NoError(t, err)
+		require.Equal(t, migrator.Latest(), version)
+	})
+}
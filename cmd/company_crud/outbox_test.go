@@ -0,0 +1,182 @@
+package main
+
+import (
+	"company-crud/internal/events"
+	jsons "company-crud/internal/handlers/http"
+	"company-crud/internal/repositories/db"
+	"company-crud/pkg/logger"
+	pkgPg "company-crud/pkg/postres"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func (s *Suite) testOutboxHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger) {
+	t.Run("Mutations are recorded in the outbox - with token", func(t *testing.T) {
+		employees := 2
+		registered := true
+		req := jsons.Create{
+			Name:            "testNameOutbox",
+			Description:     "description_1",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		}
+
+		jsonData, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		resp, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+		require.Equal(t, http.StatusCreated, status)
+
+		created := jsons.Created{}
+		err = json.Unmarshal(resp, &created)
+		require.NoError(t, err)
+
+		_, status = s.testClientDelete(t, s.token, "http://localhost:8000/companies/id/"+created.ID.String())
+		require.Equal(t, http.StatusOK, status)
+
+		var messages int
+		err = pg.Get(&messages, `SELECT COUNT(*) FROM xm_assessment.outbox WHERE aggregate_id = $1`, created.ID)
+		require.NoError(t, err)
+		require.Equal(t, 2, messages)
+	})
+
+	t.Run("Messages sent longer than the retention ago are deleted - with token", func(t *testing.T) {
+		employees := 2
+		registered := true
+		jsonData, err := json.Marshal(jsons.Create{
+			Name:            "testNameOutRet",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		})
+		require.NoError(t, err)
+
+		resp, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+		require.Equal(t, http.StatusCreated, status)
+
+		created := jsons.Created{}
+		require.NoError(t, json.Unmarshal(resp, &created))
+
+		require.Eventually(t, func() bool {
+			var pending int
+			err := pg.Get(&pending, `SELECT COUNT(*) FROM xm_assessment.outbox WHERE aggregate_id = $1 AND sent_at IS NULL`, created.ID)
+			return err == nil && pending == 0
+		}, s.testDelay, 100*time.Millisecond)
+
+		_, err = pg.Exec(`UPDATE xm_assessment.outbox SET sent_at = NOW() - INTERVAL '30 days' WHERE aggregate_id = $1`, created.ID)
+		require.NoError(t, err)
+
+		deleted, err := db.NewOutbox(pg, log).Cleanup(context.Background(), time.Now().Add(-24*time.Hour), 100)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, deleted, 1)
+
+		var messages int
+		err = pg.Get(&messages, `SELECT COUNT(*) FROM xm_assessment.outbox WHERE aggregate_id = $1`, created.ID)
+		require.NoError(t, err)
+		require.Zero(t, messages)
+	})
+
+	t.Run("Failed mutations leave no outbox message - with token", func(t *testing.T) {
+		var before int
+		err := pg.Get(&before, `SELECT COUNT(*) FROM xm_assessment.outbox`)
+		require.NoError(t, err)
+
+		_, status := s.testClientDelete(t, s.token, "http://localhost:8000/companies/"+"randomName")
+		require.Equal(t, http.StatusNotFound, status)
+
+		var after int
+		err = pg.Get(&after, `SELECT COUNT(*) FROM xm_assessment.outbox`)
+		require.NoError(t, err)
+		require.Equal(t, before, after)
+	})
+}
+
+func (s *Suite) testEventsHttpCases(t *testing.T, pg *pkgPg.Postgres) {
+	t.Run("Events carry the company snapshot - with token", func(t *testing.T) {
+		employees := 2
+		registered := true
+		req := jsons.Create{
+			Name:            "testNameEvents",
+			Description:     "description_1",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		}
+
+		jsonData, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		resp, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+		require.Equal(t, http.StatusCreated, status)
+
+		created := jsons.Created{}
+		err = json.Unmarshal(resp, &created)
+		require.NoError(t, err)
+
+		description := "description_2"
+		patchData, err := json.Marshal(jsons.Patch{Description: &description})
+		require.NoError(t, err)
+
+		_, status = s.testClientPatch(t, s.token, "http://localhost:8000/companies/id/"+created.ID.String(), patchData)
+		require.Equal(t, http.StatusOK, status)
+
+		_, status = s.testClientDelete(t, s.token, "http://localhost:8000/companies/id/"+created.ID.String())
+		require.Equal(t, http.StatusOK, status)
+
+		var rows []struct {
+			EventType string `db:"event_type"`
+			Headers   []byte `db:"headers"`
+			Payload   []byte `db:"payload"`
+		}
+		err = pg.Select(&rows, `SELECT event_type, headers, payload FROM xm_assessment.outbox WHERE aggregate_id = $1 ORDER BY id`, created.ID)
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+
+		expectedTypes := []events.Type{events.CompanyCreated, events.CompanyUpdated, events.CompanyDeleted}
+		for i, row := range rows {
+			event := events.Event{}
+			err = json.Unmarshal(row.Payload, &event)
+			require.NoError(t, err)
+			require.Equal(t, expectedTypes[i], event.Type)
+			require.Equal(t, string(expectedTypes[i]), row.EventType)
+			require.Equal(t, created.ID.String(), event.Subject)
+			require.Equal(t, events.SchemaVersion, event.SchemaVersion)
+			require.Equal(t, created.ID, event.Data.Company.ID)
+
+			headers := map[string]string{}
+			err = json.Unmarshal(row.Headers, &headers)
+			require.NoError(t, err)
+			require.Equal(t, string(expectedTypes[i]), headers["ce_type"])
+		}
+
+		require.Eventually(t, func() bool {
+			return len(s.producer.ByKey(created.ID.String())) == 3
+		}, s.testDelay, 100*time.Millisecond)
+
+		// Published messages are marked sent and their claim released.
+		require.Eventually(t, func() bool {
+			var pending int
+			err := pg.Get(&pending, `SELECT COUNT(*) FROM xm_assessment.outbox
+				WHERE aggregate_id = $1 AND (sent_at IS NULL OR claimed_by IS NOT NULL)`, created.ID)
+			return err == nil && pending == 0
+		}, s.testDelay, 100*time.Millisecond)
+
+		for i, message := range s.producer.ByKey(created.ID.String()) {
+			require.Equal(t, rows[i].Payload, message.Value)
+			require.Equal(t, string(expectedTypes[i]), message.Headers["ce_type"])
+		}
+
+		updated := events.Event{}
+		err = json.Unmarshal(rows[1].Payload, &updated)
+		require.NoError(t, err)
+		require.NotNil(t, updated.Data.Before)
+		require.Equal(t, "description_1", updated.Data.Before.Description)
+		require.Equal(t, "description_2", updated.Data.Company.Description)
+		require.Equal(t, updated.Data.Before.Version+1, updated.Data.Company.Version)
+	})
+}
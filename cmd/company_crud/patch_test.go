@@ -5,6 +5,7 @@ import (
 	"company-crud/internal/repositories/db"
 	"company-crud/pkg/logger"
 	pkgPg "company-crud/pkg/postres"
+	"context"
 	"encoding/json"
 	"github.com/stretchr/testify/require"
 	"net/http"
@@ -14,11 +15,12 @@ import (
 func (s *Suite) testPatchHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger) {
 	t.Run("Valid patch - with token", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testNamePatch_1",
 			Description:     "description_1",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -26,10 +28,10 @@ func (s *Suite) testPatchHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		_, err = companyDB.GetByName(req.Name)
+		_, err = companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 
 		employees = 1
@@ -50,7 +52,7 @@ func (s *Suite) testPatchHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger
 		_, status = s.testClientPatch(t, s.token, "http://localhost:8000/companies/"+req.Name, jsonData)
 		require.Equal(t, http.StatusOK, status)
 
-		companyDBData, err := companyDB.GetByName(postReq.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), postReq.Name)
 		require.NoError(t, err)
 
 		require.Equal(t, postReq.Name, companyDBData.Name)
@@ -62,11 +64,12 @@ func (s *Suite) testPatchHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger
 
 	t.Run("Valid patch - without token", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testNamePatch_2",
 			Description:     "description_1",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -74,10 +77,10 @@ func (s *Suite) testPatchHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		_, err = companyDB.GetByName(req.Name)
+		_, err = companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 
 		employees = 1
@@ -96,19 +99,20 @@ func (s *Suite) testPatchHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger
 		require.NoError(t, err)
 
 		_, status = s.testClientPatch(t, "", "http://localhost:8000/companies/"+req.Name, jsonData)
-		require.Equal(t, http.StatusForbidden, status)
+		require.Equal(t, http.StatusUnauthorized, status)
 
-		_, err = companyDB.GetByName(postReq.Name)
+		_, err = companyDB.GetByName(context.Background(), postReq.Name)
 		require.ErrorIs(t, err, pkgPg.NoRowsErr)
 	})
 
 	t.Run("Patch name", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testNamePatch_3",
 			Description:     "description_1",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -116,10 +120,10 @@ func (s *Suite) testPatchHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		_, err = companyDB.GetByName(req.Name)
+		_, err = companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 
 		postReq := jsons.Patch{
@@ -132,23 +136,24 @@ func (s *Suite) testPatchHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger
 		_, status = s.testClientPatch(t, s.token, "http://localhost:8000/companies/"+req.Name, jsonData)
 		require.Equal(t, http.StatusOK, status)
 
-		companyDBData, err := companyDB.GetByName(postReq.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), postReq.Name)
 		require.NoError(t, err)
 
 		require.Equal(t, postReq.Name, companyDBData.Name)
 		require.Equal(t, &req.Description, companyDBData.Description)
 		require.Equal(t, req.EmployeesNumber, companyDBData.EmployeesNumber)
-		require.Equal(t, &req.IsRegistered, companyDBData.IsRegistered)
+		require.Equal(t, req.IsRegistered, companyDBData.IsRegistered)
 		require.Equal(t, req.Type, companyDBData.Type.String())
 	})
 
 	t.Run("Patch type", func(t *testing.T) {
 		employees := 2
+		registered := true
 		req := jsons.Create{
 			Name:            "testNamePatch_4",
 			Description:     "description_1",
 			EmployeesNumber: &employees,
-			IsRegistered:    true,
+			IsRegistered:    &registered,
 			Type:            "NonProfit",
 		}
 
@@ -156,10 +161,10 @@ func (s *Suite) testPatchHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger
 		require.NoError(t, err)
 
 		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
-		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, http.StatusCreated, status)
 
 		companyDB := db.New(pg, log)
-		_, err = companyDB.GetByName(req.Name)
+		_, err = companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 
 		newType := "Sole Proprietorship"
@@ -173,13 +178,13 @@ func (s *Suite) testPatchHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger
 		_, status = s.testClientPatch(t, s.token, "http://localhost:8000/companies/"+req.Name, jsonData)
 		require.Equal(t, http.StatusOK, status)
 
-		companyDBData, err := companyDB.GetByName(req.Name)
+		companyDBData, err := companyDB.GetByName(context.Background(), req.Name)
 		require.NoError(t, err)
 
 		require.Equal(t, req.Name, companyDBData.Name)
 		require.Equal(t, &req.Description, companyDBData.Description)
 		require.Equal(t, req.EmployeesNumber, companyDBData.EmployeesNumber)
-		require.Equal(t, &req.IsRegistered, companyDBData.IsRegistered)
+		require.Equal(t, req.IsRegistered, companyDBData.IsRegistered)
 		require.Equal(t, *postReq.Type, companyDBData.Type.String())
 	})
 
@@ -196,6 +201,6 @@ func (s *Suite) testPatchHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger
 		require.NoError(t, err)
 
 		_, status := s.testClientPatch(t, s.token, "http://localhost:8000/companies/"+"randomName", jsonData)
-		require.Equal(t, http.StatusConflict, status)
+		require.Equal(t, http.StatusNotFound, status)
 	})
 }
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"company-crud/internal/domain"
+	jsons "company-crud/internal/handlers/http"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func (s *Suite) testRevisionHttpCases(t *testing.T) {
+	getCompany := func(t *testing.T, url string) jsons.Get {
+		resp, status := s.testClientGet(t, s.token, url)
+		require.Equal(t, http.StatusOK, status)
+
+		company := jsons.Get{}
+		require.NoError(t, json.Unmarshal(resp, &company))
+
+		return company
+	}
+
+	patchCompany := func(t *testing.T, name string, patch jsons.Patch) {
+		jsonData, err := json.Marshal(patch)
+		require.NoError(t, err)
+
+		_, status := s.testClientPatch(t, s.token, "http://localhost:8000/companies/"+name, jsonData)
+		require.Equal(t, http.StatusOK, status)
+	}
+
+	employees := 2
+	registered := true
+	jsonData, err := json.Marshal(jsons.Create{
+		Name:            "testNameRev_1",
+		Description:     "description_1",
+		EmployeesNumber: &employees,
+		IsRegistered:    &registered,
+		Type:            "NonProfit",
+	})
+	require.NoError(t, err)
+
+	_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+	require.Equal(t, http.StatusCreated, status)
+	created := getCompany(t, "http://localhost:8000/companies/testNameRev_1")
+
+	description := "description_2"
+	patchCompany(t, "testNameRev_1", jsons.Patch{Description: &description})
+	patched := getCompany(t, "http://localhost:8000/companies/testNameRev_1")
+
+	patchCompany(t, "testNameRev_1", jsons.Patch{Name: "testNameRev_2"})
+	renamed := getCompany(t, "http://localhost:8000/companies/testNameRev_2")
+
+	t.Run("Revisions are listed - with token", func(t *testing.T) {
+		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameRev_2/revisions")
+		require.Equal(t, http.StatusOK, status)
+
+		revisions := jsons.RevisionList{}
+		require.NoError(t, json.Unmarshal(resp, &revisions))
+		require.Len(t, revisions.Revisions, 3)
+		require.Empty(t, revisions.NextCursor)
+
+		for i, operation := range []domain.AuditOperation{domain.AuditUpdate, domain.AuditUpdate, domain.AuditCreate} {
+			revision := revisions.Revisions[i]
+			require.Equal(t, string(operation), revision.Operation)
+			require.Equal(t, created.Version+2-i, revision.Version)
+			require.Equal(t, revision.Version, revision.Company.Version)
+			require.Equal(t, created.ID, revision.Company.ID)
+		}
+		require.Equal(t, "testNameRev_2", revisions.Revisions[0].Company.Name)
+		require.Equal(t, "description_2", revisions.Revisions[1].Company.Description)
+		require.Equal(t, "description_1", revisions.Revisions[2].Company.Description)
+
+		// Paging one revision at a time.
+		query := url.Values{"limit": {"1"}}
+		seen := 0
+		for {
+			resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameRev_2/revisions?"+query.Encode())
+			require.Equal(t, http.StatusOK, status)
+
+			revisions := jsons.RevisionList{}
+			require.NoError(t, json.Unmarshal(resp, &revisions))
+			require.Len(t, revisions.Revisions, 1)
+			seen++
+
+			if revisions.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", revisions.NextCursor)
+		}
+		require.Equal(t, 3, seen)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameRev_1/revisions")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Revision is read - with token", func(t *testing.T) {
+		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameRev_2/revisions/"+strconv.Itoa(created.Version))
+		require.Equal(t, http.StatusOK, status)
+
+		revision := jsons.Revision{}
+		require.NoError(t, json.Unmarshal(resp, &revision))
+		require.Equal(t, string(domain.AuditCreate), revision.Operation)
+		require.Equal(t, "testNameRev_1", revision.Company.Name)
+		require.Equal(t, "description_1", revision.Company.Description)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameRev_2/revisions/100")
+		require.Equal(t, http.StatusNotFound, status)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameRev_2/revisions/first")
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Company is read as of a time - with token", func(t *testing.T) {
+		asOf := func(name string, at time.Time) string {
+			return "http://localhost:8000/companies/" + name + "?" + url.Values{"as_of": {at.Format(time.RFC3339Nano)}}.Encode()
+		}
+
+		// The name is looked up as it was then, not as it is now.
+		company := getCompany(t, asOf("testNameRev_1", created.UpdatedAt))
+		require.Equal(t, created.ID, company.ID)
+		require.Equal(t, "description_1", company.Description)
+		require.Equal(t, created.Version, company.Version)
+
+		company = getCompany(t, asOf("testNameRev_1", patched.UpdatedAt))
+		require.Equal(t, "description_2", company.Description)
+
+		_, status := s.testClientGet(t, s.token, asOf("testNameRev_1", renamed.UpdatedAt))
+		require.Equal(t, http.StatusNotFound, status)
+
+		company = getCompany(t, asOf("testNameRev_2", renamed.UpdatedAt))
+		require.Equal(t, renamed.Version, company.Version)
+
+		_, status = s.testClientGet(t, s.token, asOf("testNameRev_1", created.UpdatedAt.Add(-time.Millisecond)))
+		require.Equal(t, http.StatusNotFound, status)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameRev_2?as_of=yesterday")
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Company is reverted - with token", func(t *testing.T) {
+		_, _, status := s.testClientRequest(t, http.MethodPost, s.token,
+			"http://localhost:8000/companies/testNameRev_2/revisions/"+strconv.Itoa(created.Version)+"/revert",
+			map[string]string{"If-Match": `"stale"`}, nil)
+		require.Equal(t, http.StatusPreconditionFailed, status)
+
+		resp, headers, status := s.testClientRequest(t, http.MethodPost, s.token,
+			"http://localhost:8000/companies/testNameRev_2/revisions/"+strconv.Itoa(created.Version)+"/revert", nil, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.NotEmpty(t, headers.Get("ETag"))
+
+		reverted := jsons.Get{}
+		require.NoError(t, json.Unmarshal(resp, &reverted))
+		require.Equal(t, created.ID, reverted.ID)
+		require.Equal(t, "testNameRev_1", reverted.Name)
+		require.Equal(t, "description_1", reverted.Description)
+		require.Equal(t, renamed.Version+1, reverted.Version)
+
+		resp, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameRev_1/revisions?limit=1")
+		require.Equal(t, http.StatusOK, status)
+
+		revisions := jsons.RevisionList{}
+		require.NoError(t, json.Unmarshal(resp, &revisions))
+		require.Equal(t, string(domain.AuditRevert), revisions.Revisions[0].Operation)
+		require.Equal(t, reverted.Version, revisions.Revisions[0].Version)
+
+		_, status = s.testClientPost(t, s.token, "http://localhost:8000/companies/testNameRev_1/revisions/100/revert", nil)
+		require.Equal(t, http.StatusNotFound, status)
+
+		readOnly := s.signToken(t, domain.ScopeCompaniesRead)
+		_, status = s.testClientPost(t, readOnly, "http://localhost:8000/companies/testNameRev_1/revisions/1/revert", nil)
+		require.Equal(t, http.StatusForbidden, status)
+	})
+}
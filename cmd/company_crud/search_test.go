@@ -0,0 +1,164 @@
+package main
+
+import (
+	jsons "company-crud/internal/handlers/http"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func (s *Suite) testSearchHttpCases(t *testing.T) {
+	createCompany := func(t *testing.T, name, description, companyType string) {
+		employees := 10
+		registered := true
+		jsonData, err := json.Marshal(jsons.Create{
+			Name:            name,
+			Description:     description,
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            companyType,
+		})
+		require.NoError(t, err)
+
+		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+		require.Equal(t, http.StatusCreated, status)
+	}
+
+	searchCompanies := func(t *testing.T, params url.Values) (jsons.SearchList, int) {
+		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/search?"+params.Encode())
+
+		list := jsons.SearchList{}
+		if status == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp, &list))
+		}
+
+		return list, status
+	}
+
+	names := func(list jsons.SearchList) []string {
+		result := make([]string, 0, len(list.Results))
+		for _, found := range list.Results {
+			result = append(result, found.Company.Name)
+		}
+
+		return result
+	}
+
+	createCompany(t, "Heliotrope", "Photovoltaic panels for rooftops", "Cooperative")
+	createCompany(t, "Photovolt Coop", "A cooperative building photovoltaic farms and photovoltaic storage", "Cooperative")
+	createCompany(t, "Windmillers", "Turbines on the coast", "Corporations")
+
+	t.Run("Companies are ranked by relevance - with token", func(t *testing.T) {
+		list, status := searchCompanies(t, url.Values{"q": {"photovoltaic"}})
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"Photovolt Coop", "Heliotrope"}, names(list))
+		require.Greater(t, list.Results[0].Rank, list.Results[1].Rank)
+		require.Empty(t, list.NextCursor)
+
+		require.Equal(t, "Heliotrope", list.Results[1].NameHighlight)
+		require.Contains(t, list.Results[1].Snippet, "<mark>Photovoltaic</mark> panels")
+		require.Equal(t, "Cooperative", list.Results[1].Company.Type)
+	})
+
+	t.Run("Words are stemmed and matched in names too - with token", func(t *testing.T) {
+		list, status := searchCompanies(t, url.Values{"q": {"turbine coast"}})
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"Windmillers"}, names(list))
+		require.Contains(t, list.Results[0].Snippet, "<mark>Turbines</mark>")
+
+		list, status = searchCompanies(t, url.Values{"q": {"heliotropes"}})
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"Heliotrope"}, names(list))
+		require.Equal(t, "<mark>Heliotrope</mark>", list.Results[0].NameHighlight)
+	})
+
+	t.Run("Names are matched despite typos - with token", func(t *testing.T) {
+		list, status := searchCompanies(t, url.Values{"q": {"Windmilers"}})
+		require.Equal(t, http.StatusOK, status)
+		require.NotEmpty(t, list.Results)
+		require.Equal(t, "Windmillers", list.Results[0].Company.Name)
+	})
+
+	t.Run("Highlights are HTML escaped - with token", func(t *testing.T) {
+		createCompany(t, "Mirrorworks", "Mirrors & lenses <3 \"polished\" <script>alert(1)</script> \x02glass\x03", "Corporations")
+
+		list, status := searchCompanies(t, url.Values{"q": {"mirrors"}})
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"Mirrorworks"}, names(list))
+
+		snippet := list.Results[0].Snippet
+		require.Contains(t, snippet, "<mark>Mirrors</mark> &amp; lenses &lt;3 &#34;polished&#34;")
+		require.NotContains(t, snippet, "<script>")
+		require.NotContains(t, snippet, "\x02")
+		require.Equal(t, 1, strings.Count(snippet, "<mark>"))
+	})
+
+	t.Run("Results are filtered - with token", func(t *testing.T) {
+		list, status := searchCompanies(t, url.Values{"q": {"photovoltaic"}, "type": {"Corporations"}})
+		require.Equal(t, http.StatusOK, status)
+		require.Empty(t, list.Results)
+
+		list, status = searchCompanies(t, url.Values{"q": {"photovoltaic"}, "type": {"Cooperative"}, "registered": {"true"}})
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, list.Results, 2)
+
+		list, status = searchCompanies(t, url.Values{"q": {"photovoltaic"}, "registered": {"false"}})
+		require.Equal(t, http.StatusOK, status)
+		require.Empty(t, list.Results)
+	})
+
+	t.Run("Results are paginated - with token", func(t *testing.T) {
+		first, status := searchCompanies(t, url.Values{"q": {"photovoltaic"}, "limit": {"1"}})
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"Photovolt Coop"}, names(first))
+		require.NotEmpty(t, first.NextCursor)
+
+		second, status := searchCompanies(t, url.Values{"q": {"photovoltaic"}, "limit": {"1"}, "cursor": {first.NextCursor}})
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"Heliotrope"}, names(second))
+		require.Empty(t, second.NextCursor)
+	})
+
+	t.Run("Deleted companies aren't found - with token", func(t *testing.T) {
+		_, status := s.testClientDelete(t, s.token, "http://localhost:8000/companies/Windmillers")
+		require.Equal(t, http.StatusOK, status)
+
+		list, status := searchCompanies(t, url.Values{"q": {"turbine"}})
+		require.Equal(t, http.StatusOK, status)
+		require.Empty(t, list.Results)
+	})
+
+	t.Run("Invalid searches are rejected - with token", func(t *testing.T) {
+		_, status := searchCompanies(t, url.Values{})
+		require.Equal(t, http.StatusBadRequest, status)
+
+		_, status = searchCompanies(t, url.Values{"q": {"photovoltaic"}, "cursor": {"not-a-cursor"}})
+		require.Equal(t, http.StatusBadRequest, status)
+
+		_, status = searchCompanies(t, url.Values{"q": {"photovoltaic"}, "type": {"Unknown"}})
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Search name is reserved - with token", func(t *testing.T) {
+		employees := 1
+		registered := true
+		jsonData, err := json.Marshal(jsons.Create{
+			Name:            "search",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "Cooperative",
+		})
+		require.NoError(t, err)
+
+		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+		require.Equal(t, http.StatusUnprocessableEntity, status)
+	})
+
+	t.Run("Search - without token", func(t *testing.T) {
+		_, status := s.testClientGet(t, "", "http://localhost:8000/companies/search?q=photovoltaic")
+		require.Equal(t, http.StatusUnauthorized, status)
+	})
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"company-crud/internal/app"
+	"company-crud/internal/domain"
+	jsons "company-crud/internal/handlers/http"
+	"company-crud/internal/repositories/db"
+	"company-crud/pkg/logger"
+	pkgPg "company-crud/pkg/postres"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func (s *Suite) testSoftDeleteHttpCases(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger) {
+	adminToken := s.signToken(t, domain.ScopeCompaniesRead, domain.ScopeCompaniesWrite, domain.ScopeCompaniesDelete,
+		domain.ScopeAuditRead, domain.ScopeCompaniesAdmin)
+
+	createCompany := func(t *testing.T, name string) jsons.Get {
+		employees := 2
+		registered := true
+		jsonData, err := json.Marshal(jsons.Create{
+			Name:            name,
+			Description:     "description_1",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		})
+		require.NoError(t, err)
+
+		_, status := s.testClientPost(t, s.token, "http://localhost:8000/companies", jsonData)
+		require.Equal(t, http.StatusCreated, status)
+
+		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/"+name)
+		require.Equal(t, http.StatusOK, status)
+
+		company := jsons.Get{}
+		require.NoError(t, json.Unmarshal(resp, &company))
+
+		return company
+	}
+
+	t.Run("Deleted companies are hidden - with token", func(t *testing.T) {
+		created := createCompany(t, "testNameSoftDel_1")
+
+		_, status := s.testClientDelete(t, s.token, "http://localhost:8000/companies/testNameSoftDel_1")
+		require.Equal(t, http.StatusOK, status)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameSoftDel_1")
+		require.Equal(t, http.StatusNotFound, status)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/id/"+created.ID.String())
+		require.Equal(t, http.StatusNotFound, status)
+
+		_, status = s.testClientDelete(t, s.token, "http://localhost:8000/companies/testNameSoftDel_1")
+		require.Equal(t, http.StatusNotFound, status)
+
+		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies?limit=100")
+		require.Equal(t, http.StatusOK, status)
+
+		list := jsons.List{}
+		require.NoError(t, json.Unmarshal(resp, &list))
+		for _, company := range list.Companies {
+			require.NotEqual(t, created.ID, company.ID)
+			require.Nil(t, company.DeletedAt)
+		}
+
+		// Only admins may look at the deleted companies.
+		for _, url := range []string{
+			"http://localhost:8000/companies/testNameSoftDel_1?include_deleted=true",
+			"http://localhost:8000/companies/id/" + created.ID.String() + "?include_deleted=true",
+			"http://localhost:8000/companies?include_deleted=true",
+		} {
+			_, headers, status := s.testClientRequest(t, http.MethodGet, s.token, url, nil, nil)
+			require.Equal(t, http.StatusForbidden, status)
+			require.Contains(t, headers.Get("WWW-Authenticate"), domain.ScopeCompaniesAdmin)
+		}
+
+		_, status = s.testClientGet(t, adminToken, "http://localhost:8000/companies/testNameSoftDel_1?include_deleted=maybe")
+		require.Equal(t, http.StatusBadRequest, status)
+
+		for _, url := range []string{
+			"http://localhost:8000/companies/testNameSoftDel_1?include_deleted=true",
+			"http://localhost:8000/companies/id/" + created.ID.String() + "?include_deleted=true",
+		} {
+			resp, status := s.testClientGet(t, adminToken, url)
+			require.Equal(t, http.StatusOK, status)
+
+			company := jsons.Get{}
+			require.NoError(t, json.Unmarshal(resp, &company))
+			require.Equal(t, created.ID, company.ID)
+			require.NotNil(t, company.DeletedAt)
+			require.Equal(t, created.Version+1, company.Version)
+		}
+
+		found := false
+		query := "include_deleted=true&limit=100"
+		for !found {
+			resp, status := s.testClientGet(t, adminToken, "http://localhost:8000/companies?"+query)
+			require.Equal(t, http.StatusOK, status)
+
+			list := jsons.List{}
+			require.NoError(t, json.Unmarshal(resp, &list))
+			for _, company := range list.Companies {
+				found = found || company.ID == created.ID
+			}
+			if list.NextCursor == "" {
+				break
+			}
+			query = "include_deleted=true&limit=100&cursor=" + list.NextCursor
+		}
+		require.True(t, found)
+	})
+
+	t.Run("Deleted companies can be restored - with token", func(t *testing.T) {
+		created := createCompany(t, "testNameSoftDel_2")
+
+		_, status := s.testClientDelete(t, s.token, "http://localhost:8000/companies/testNameSoftDel_2")
+		require.Equal(t, http.StatusOK, status)
+
+		resp, status := s.testClientPost(t, s.token, "http://localhost:8000/companies/testNameSoftDel_2/restore", nil)
+		require.Equal(t, http.StatusOK, status)
+
+		restored := jsons.Get{}
+		require.NoError(t, json.Unmarshal(resp, &restored))
+		require.Equal(t, created.ID, restored.ID)
+		require.Nil(t, restored.DeletedAt)
+		require.Equal(t, created.Version+2, restored.Version)
+
+		_, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameSoftDel_2")
+		require.Equal(t, http.StatusOK, status)
+
+		_, status = s.testClientPost(t, s.token, "http://localhost:8000/companies/testNameSoftDel_2/restore", nil)
+		require.Equal(t, http.StatusNotFound, status)
+
+		resp, status = s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameSoftDel_2/audit")
+		require.Equal(t, http.StatusOK, status)
+
+		audit := jsons.AuditList{}
+		require.NoError(t, json.Unmarshal(resp, &audit))
+		require.Len(t, audit.Entries, 3)
+		require.Equal(t, string(domain.AuditRestore), audit.Entries[0].Operation)
+
+		readOnly := s.signToken(t, domain.ScopeCompaniesRead)
+		_, status = s.testClientPost(t, readOnly, "http://localhost:8000/companies/testNameSoftDel_2/restore", nil)
+		require.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("Names of deleted companies can be reused - with token", func(t *testing.T) {
+		deleted := createCompany(t, "testNameSoftDel_3")
+
+		_, status := s.testClientDelete(t, s.token, "http://localhost:8000/companies/testNameSoftDel_3")
+		require.Equal(t, http.StatusOK, status)
+
+		reused := createCompany(t, "testNameSoftDel_3")
+		require.NotEqual(t, deleted.ID, reused.ID)
+
+		// The name is taken again, the deleted company can't get it back.
+		_, status = s.testClientPost(t, s.token, "http://localhost:8000/companies/testNameSoftDel_3/restore", nil)
+		require.Equal(t, http.StatusConflict, status)
+
+		// The company not deleted is found first.
+		resp, status := s.testClientGet(t, adminToken, "http://localhost:8000/companies/testNameSoftDel_3?include_deleted=true")
+		require.Equal(t, http.StatusOK, status)
+
+		company := jsons.Get{}
+		require.NoError(t, json.Unmarshal(resp, &company))
+		require.Equal(t, reused.ID, company.ID)
+	})
+
+	t.Run("Deleted companies are purged after the retention", func(t *testing.T) {
+		deleted := createCompany(t, "testNameSoftDel_4")
+		kept := createCompany(t, "testNameSoftDel_5")
+
+		for _, name := range []string{"testNameSoftDel_4", "testNameSoftDel_5"} {
+			_, status := s.testClientDelete(t, s.token, "http://localhost:8000/companies/"+name)
+			require.Equal(t, http.StatusOK, status)
+		}
+
+		_, err := pg.Exec(`UPDATE xm_assessment.companies SET deleted_at = NOW() - INTERVAL '2 days' WHERE id = $1`, deleted.ID)
+		require.NoError(t, err)
+
+		purgeJob := app.NewPurgeJob(log, app.PurgeConfig{Retention: 24 * time.Hour, BatchSize: 1}, db.New(pg, log))
+		purged, err := purgeJob.Purge(context.Background())
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, purged, 1)
+
+		_, status := s.testClientGet(t, adminToken, "http://localhost:8000/companies/id/"+deleted.ID.String()+"?include_deleted=true")
+		require.Equal(t, http.StatusNotFound, status)
+
+		_, status = s.testClientPost(t, s.token, "http://localhost:8000/companies/testNameSoftDel_4/restore", nil)
+		require.Equal(t, http.StatusNotFound, status)
+
+		_, status = s.testClientGet(t, adminToken, "http://localhost:8000/companies/id/"+kept.ID.String()+"?include_deleted=true")
+		require.Equal(t, http.StatusOK, status)
+
+		resp, status := s.testClientGet(t, s.token, "http://localhost:8000/companies/testNameSoftDel_4/audit")
+		require.Equal(t, http.StatusOK, status)
+
+		audit := jsons.AuditList{}
+		require.NoError(t, json.Unmarshal(resp, &audit))
+		require.NotEmpty(t, audit.Entries)
+		require.Equal(t, string(domain.AuditPurge), audit.Entries[0].Operation)
+		require.Equal(t, "system:purge", audit.Entries[0].Actor)
+	})
+}
@@ -4,15 +4,19 @@ import (
 	"bytes"
 	"company-crud/pkg/logger"
 	pkgPg "company-crud/pkg/postres"
+	"company-crud/pkg/producer"
 	"context"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/kafka"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"go.uber.org/zap/zaptest/observer"
 	"io"
 	"log"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 )
@@ -20,11 +24,15 @@ import (
 type Suite struct {
 	postgresContainer *postgres.PostgresContainer
 	kafkaContainer    *kafka.KafkaContainer
+	producer          *producer.Memory
+	logs              *observer.ObservedLogs
 	testDelay         time.Duration
+	tokenCfg          Config
+	keys              testKeys
 	token             string
 }
 
-func New(t *testing.T, testDelay time.Duration, token string) *Suite {
+func New(t *testing.T, testDelay time.Duration) *Suite {
 	pc := initPostgres(t, context.Background(), "company-db", "user", "passwd")
 	t.Cleanup(func() {
 		if err := testcontainers.TerminateContainer(pc); err != nil {
@@ -43,14 +51,30 @@ func New(t *testing.T, testDelay time.Duration, token string) *Suite {
 		postgresContainer: pc,
 		kafkaContainer:    kc,
 		testDelay:         testDelay,
-		token:             token,
 	}
 }
 
+// signToken issues a token, valid for an hour, granting scopes.
+func (s *Suite) signToken(t *testing.T, scopes ...string) string {
+	return s.signTokenWithClaims(t, jwt.MapClaims{
+		"sub":   "integration-test",
+		"iss":   s.tokenCfg.TokenIssuer,
+		"aud":   s.tokenCfg.TokenAudience,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": strings.Join(scopes, " "),
+	})
+}
+
+func (s *Suite) signTokenWithClaims(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.tokenCfg.TokenSignature))
+	require.NoError(t, err)
+
+	return token
+}
+
 func initPostgres(t *testing.T, ctx context.Context, dbName, dbUser, dbPassword string) *postgres.PostgresContainer {
 	postgresContainer, err := postgres.Run(ctx,
 		"postgres:16-alpine",
-		postgres.WithInitScripts("../../scripts/init.sql"),
 		postgres.WithDatabase(dbName),
 		postgres.WithUsername(dbUser),
 		postgres.WithPassword(dbPassword),
@@ -79,7 +103,9 @@ func (s *Suite) testClientPost(t *testing.T, token, url string, data []byte) ([]
 	require.NoError(t, err)
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Token", token)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -98,7 +124,9 @@ func (s *Suite) testClientGet(t *testing.T, token, url string) ([]byte, int) {
 	require.NoError(t, err)
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Token", token)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -117,7 +145,9 @@ func (s *Suite) testClientDelete(t *testing.T, token, url string) ([]byte, int)
 	require.NoError(t, err)
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Token", token)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -136,7 +166,9 @@ func (s *Suite) testClientPatch(t *testing.T, token, url string, data []byte) ([
 	require.NoError(t, err)
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Token", token)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -150,6 +182,32 @@ func (s *Suite) testClientPatch(t *testing.T, token, url string, data []byte) ([
 	return body, resp.StatusCode
 }
 
+// testClientRequest is the generic flavour of the helpers above, for cases that need
+// extra request headers or inspect the response ones.
+func (s *Suite) testClientRequest(t *testing.T, method, token, url string, headers map[string]string, data []byte) ([]byte, http.Header, int) {
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(data))
+	require.NoError(t, err)
+
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return body, resp.Header, resp.StatusCode
+}
+
 func (s *Suite) startTests(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger) {
 	time.Sleep(s.testDelay)
 
@@ -169,4 +227,84 @@ func (s *Suite) startTests(t *testing.T, pg *pkgPg.Postgres, log *logger.Logger)
 	t.Run("Test CompanyPatch", func(t *testing.T) {
 		s.testPatchHttpCases(t, pg, log)
 	})
+
+	t.Run("Test CompanyList", func(t *testing.T) {
+		s.testListHttpCases(t, pg, log)
+	})
+
+	t.Run("Test CompanyByID", func(t *testing.T) {
+		s.testIDHttpCases(t, pg, log)
+	})
+
+	t.Run("Test CompanyETag", func(t *testing.T) {
+		s.testETagHttpCases(t, pg, log)
+	})
+
+	t.Run("Test CompanyIdempotency", func(t *testing.T) {
+		s.testIdempotencyHttpCases(t, pg, log)
+	})
+
+	t.Run("Test CompanyOutbox", func(t *testing.T) {
+		s.testOutboxHttpCases(t, pg, log)
+	})
+
+	t.Run("Test CompanyEvents", func(t *testing.T) {
+		s.testEventsHttpCases(t, pg)
+	})
+
+	t.Run("Test Tracing", func(t *testing.T) {
+		s.testTracingHttpCases(t, pg)
+	})
+
+	t.Run("Test Audit", func(t *testing.T) {
+		s.testAuditHttpCases(t, pg)
+	})
+
+	t.Run("Test SoftDelete", func(t *testing.T) {
+		s.testSoftDeleteHttpCases(t, pg, log)
+	})
+
+	t.Run("Test Revisions", func(t *testing.T) {
+		s.testRevisionHttpCases(t)
+	})
+
+	t.Run("Test Batch", func(t *testing.T) {
+		s.testBatchHttpCases(t, pg)
+	})
+
+	t.Run("Test Import", func(t *testing.T) {
+		s.testImportHttpCases(t)
+	})
+
+	t.Run("Test Export", func(t *testing.T) {
+		s.testExportHttpCases(t)
+	})
+
+	t.Run("Test Search", func(t *testing.T) {
+		s.testSearchHttpCases(t)
+	})
+
+	t.Run("Test Auth", func(t *testing.T) {
+		s.testAuthHttpCases(t)
+	})
+
+	t.Run("Test JWKS", func(t *testing.T) {
+		s.testJWKSHttpCases(t)
+	})
+
+	t.Run("Test Access Log", func(t *testing.T) {
+		s.testAccessLogHttpCases(t)
+	})
+
+	t.Run("Test Health", func(t *testing.T) {
+		s.testHealthHttpCases(t)
+	})
+
+	t.Run("Test Metrics", func(t *testing.T) {
+		s.testMetricsHttpCases(t)
+	})
+
+	t.Run("Test Migrations", func(t *testing.T) {
+		s.testMigrationCases(t, pg, log)
+	})
 }
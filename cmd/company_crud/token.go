@@ -0,0 +1,56 @@
+package main
+
+import (
+	"company-crud/internal/domain"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// runToken executes the token subcommand, printing a token signed with the configured
+// shared secret, for local use.
+func runToken(cfg Config, args []string) error {
+	flags := flag.NewFlagSet("token", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	subject := flags.String("sub", "local", "subject of the token")
+	scope := flags.String("scope", strings.Join([]string{
+		domain.ScopeCompaniesRead,
+		domain.ScopeCompaniesWrite,
+		domain.ScopeCompaniesDelete,
+		domain.ScopeCompaniesAdmin,
+		domain.ScopeAuditRead,
+	}, " "), "space separated scopes granted")
+	ttl := flags.Duration("ttl", time.Hour, "how long the token is valid")
+
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("usage: company_crud token [-sub subject] [-scope scopes] [-ttl duration]: %w", err)
+	}
+	if cfg.TokenSignature == "" {
+		return errors.New("JWT_TOKEN_SIGNATURE isn't set")
+	}
+
+	claims := jwt.MapClaims{
+		"sub":   *subject,
+		"exp":   time.Now().Add(*ttl).Unix(),
+		"scope": *scope,
+	}
+	if cfg.TokenIssuer != "" {
+		claims["iss"] = cfg.TokenIssuer
+	}
+	if cfg.TokenAudience != "" {
+		claims["aud"] = cfg.TokenAudience
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.TokenSignature))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(os.Stdout, token)
+	return err
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	jsons "company-crud/internal/handlers/http"
+	pkgPg "company-crud/pkg/postres"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func (s *Suite) testTracingHttpCases(t *testing.T, pg *pkgPg.Postgres) {
+	t.Run("Trace context reaches the event headers - with token", func(t *testing.T) {
+		traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+
+		employees := 2
+		registered := true
+		req := jsons.Create{
+			Name:            "testNameTrace",
+			Description:     "description_1",
+			EmployeesNumber: &employees,
+			IsRegistered:    &registered,
+			Type:            "NonProfit",
+		}
+
+		jsonData, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		resp, _, status := s.testClientRequest(t, http.MethodPost, s.token, "http://localhost:8000/companies",
+			map[string]string{"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01"}, jsonData)
+		require.Equal(t, http.StatusCreated, status)
+
+		created := jsons.Created{}
+		err = json.Unmarshal(resp, &created)
+		require.NoError(t, err)
+
+		var rawHeaders []byte
+		err = pg.Get(&rawHeaders, `SELECT headers FROM xm_assessment.outbox WHERE aggregate_id = $1`, created.ID)
+		require.NoError(t, err)
+
+		headers := map[string]string{}
+		err = json.Unmarshal(rawHeaders, &headers)
+		require.NoError(t, err)
+		require.True(t, strings.Contains(headers["traceparent"], traceID))
+
+		require.Eventually(t, func() bool {
+			return len(s.producer.ByKey(created.ID.String())) == 1
+		}, s.testDelay, 100*time.Millisecond)
+		require.True(t, strings.Contains(s.producer.ByKey(created.ID.String())[0].Headers["traceparent"], traceID))
+	})
+}
@@ -5,17 +5,32 @@ import (
 	"company-crud/internal/repositories/db"
 	"company-crud/internal/services"
 	"company-crud/pkg/http_server"
+	"company-crud/pkg/jwtkeys"
 	"company-crud/pkg/logger"
 	"company-crud/pkg/postres"
 	"company-crud/pkg/producer"
 	"context"
 	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"time"
 )
 
 type Config struct {
-	TokenSignature string
+	TokenKeys     *jwtkeys.KeySet
+	TokenIssuer   string
+	TokenAudience string
+	// LegacyTokenHeader still accepts tokens sent in the Token header.
+	LegacyTokenHeader bool
+	IdempotencyTTL    time.Duration
+	RequestTimeout    time.Duration
+	// BatchSummaryEvent publishes a single event per batch by default.
+	BatchSummaryEvent bool
+	// DrainDelay is how long /readyz reports not ready before the server stops accepting
+	// connections, leaving load balancers time to stop routing to the instance.
+	DrainDelay time.Duration
+	Relay      RelayConfig
+	Purge      PurgeConfig
 }
 
 type CompanyCRUD struct {
@@ -23,11 +38,11 @@ type CompanyCRUD struct {
 	log             *logger.Logger
 	server          *http_server.Server
 	db              *postres.Postgres
-	producer        *producer.KafkaProducer
+	producer        producer.Produce
 	cfg             Config
 }
 
-func New(ctx context.Context, log *logger.Logger, cfg Config, server *http_server.Server, db *postres.Postgres, producer *producer.KafkaProducer) *CompanyCRUD {
+func New(ctx context.Context, log *logger.Logger, cfg Config, server *http_server.Server, db *postres.Postgres, producer producer.Produce) *CompanyCRUD {
 	return &CompanyCRUD{
 		osSignalContext: ctx,
 		log:             log,
@@ -42,10 +57,50 @@ func (cc *CompanyCRUD) Run() {
 	cc.log.Info("Company CRUD started...")
 
 	companyDB := db.New(cc.db, cc.log)
-	companyService := services.New(cc.log, cc.producer, companyDB)
-	companyHttp := http.New(cc.log, companyService, cc.cfg.TokenSignature)
+	companyService := services.New(cc.log, companyDB)
+	idempotencyDB := db.NewIdempotency(cc.db, cc.log)
+	companyHttp := http.New(cc.log, companyService, idempotencyDB, http.Config{
+		TokenKeys:         cc.cfg.TokenKeys.Keyfunc,
+		TokenIssuer:       cc.cfg.TokenIssuer,
+		TokenAudience:     cc.cfg.TokenAudience,
+		LegacyTokenHeader: cc.cfg.LegacyTokenHeader,
+		IdempotencyTTL:    cc.cfg.IdempotencyTTL,
+		RequestTimeout:    cc.cfg.RequestTimeout,
+		BatchSummaryEvent: cc.cfg.BatchSummaryEvent,
+	})
 
 	cc.server.CreateRoutes(companyHttp)
+
+	if err := cc.server.RegisterMetrics(cc.db.Collector()); err != nil {
+		cc.log.Error("registering db metrics failed", zap.Error(err))
+	}
+	if instrumented, ok := cc.producer.(interface{ Collector() prometheus.Collector }); ok {
+		if err := cc.server.RegisterMetrics(instrumented.Collector()); err != nil {
+			cc.log.Error("registering producer metrics failed", zap.Error(err))
+		}
+	}
+
+	cc.server.AddReadinessCheck("postgres", cc.db.PingContext)
+	if pinger, ok := cc.producer.(interface{ Ping(context.Context) error }); ok {
+		cc.server.AddReadinessCheck("kafka", pinger.Ping)
+	}
+
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	outboxRelay := NewOutboxRelay(cc.log, cc.cfg.Relay, db.NewOutbox(cc.db, cc.log), cc.producer)
+	go func() {
+		defer close(relayDone)
+		outboxRelay.Run(relayCtx)
+	}()
+
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	purgeDone := make(chan struct{})
+	purgeJob := NewPurgeJob(cc.log, cc.cfg.Purge, companyDB)
+	go func() {
+		defer close(purgeDone)
+		purgeJob.Run(purgeCtx)
+	}()
+
 	go func() {
 		cc.log.Info(fmt.Sprintf("Listening on: %s", "8000"))
 		err := cc.server.Start()
@@ -59,18 +114,28 @@ func (cc *CompanyCRUD) Run() {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 		cc.log.Info("shutting down gracefully...")
+		cc.server.Drain()
+		time.Sleep(cc.cfg.DrainDelay)
 
 		cc.log.Info("shutting down Server...")
 		if err := cc.server.Stop(ctx); err != nil {
 			cc.log.Fatal("server shutdown failed: %v", zap.Error(err))
 		}
 
+		cc.log.Info("shutting down outbox relay...")
+		stopRelay()
+		<-relayDone
+
+		cc.log.Info("shutting down purge job...")
+		stopPurge()
+		<-purgeDone
+
 		cc.log.Info("shutting down db...")
 		if err := cc.db.Stop(); err != nil {
 			cc.log.Fatal("db shutdown failed: %v", zap.Error(err))
 		}
 
-		cc.log.Info("shutting down kafka producer...")
+		cc.log.Info("shutting down event producer...")
 		cc.producer.Stop()
 	}
 }
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/pkg/logger"
+	"company-crud/pkg/producer"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+	"time"
+)
+
+const relayErrorSection = "outboxRelay"
+
+const (
+	defaultRelayPollInterval = time.Second
+	defaultRelayBatchSize    = 100
+	defaultRelayClaimTTL     = 5 * time.Minute
+	defaultRelayRetention    = 7 * 24 * time.Hour
+	// relayCleanupInterval is how often the messages sent longer than the retention ago
+	// are deleted.
+	relayCleanupInterval = time.Hour
+	// maxRelayBackoff caps the wait between attempts while publishing keeps failing.
+	maxRelayBackoff = time.Minute
+)
+
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// ClaimTTL bounds how long a batch may take to publish. Past it, the messages left are
+	// released and another relay may take them over.
+	ClaimTTL time.Duration
+	// Retention is how long sent messages are kept before being deleted.
+	Retention time.Duration
+}
+
+// OutboxRelay publishes the outbox messages written along with company changes. Messages
+// are published at least once and in order: a failing message is retried, with exponential
+// backoff, before any later one is sent.
+type OutboxRelay struct {
+	outboxDB domain.OutboxDB
+	producer producer.Produce
+	log      *logger.Logger
+	cfg      RelayConfig
+}
+
+func NewOutboxRelay(log *logger.Logger, cfg RelayConfig, outboxDB domain.OutboxDB, prod producer.Produce) *OutboxRelay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultRelayPollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultRelayBatchSize
+	}
+	if cfg.ClaimTTL <= 0 {
+		cfg.ClaimTTL = defaultRelayClaimTTL
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaultRelayRetention
+	}
+
+	return &OutboxRelay{
+		outboxDB: outboxDB,
+		producer: prod,
+		log:      log,
+		cfg:      cfg,
+	}
+}
+
+// Run relays until ctx is done, and deletes the messages sent longer than the retention
+// ago every relayCleanupInterval.
+func (or *OutboxRelay) Run(ctx context.Context) {
+	log := or.log.Named(relayErrorSection)
+	failures := 0
+	var cleanedAt time.Time
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		sent, err := or.outboxDB.Relay(ctx, or.cfg.BatchSize, or.cfg.ClaimTTL, func(ctx context.Context, msg domain.OutboxMessage) error {
+			// Publishing continues the trace of the request that made the change.
+			ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Headers))
+
+			return or.producer.ProduceEvent(ctx, producer.Message{
+				Key:     []byte(msg.AggregateID.String()),
+				Value:   msg.Payload,
+				Headers: msg.Headers,
+			})
+		})
+
+		if time.Since(cleanedAt) >= relayCleanupInterval {
+			cleanedAt = time.Now()
+
+			deleted, err := or.Cleanup(ctx)
+			switch {
+			case err != nil:
+				log.Error("deleting sent outbox messages failed", zap.Error(err), zap.Int("deleted", deleted))
+			case deleted > 0:
+				log.Info(fmt.Sprintf("%d sent outbox messages deleted", deleted))
+			}
+		}
+
+		wait := or.cfg.PollInterval
+		switch {
+		case err != nil:
+			failures++
+			wait = backoff(or.cfg.PollInterval, failures)
+			log.Error(fmt.Sprintf("relaying outbox failed, retrying in %s", wait), zap.Error(err), zap.Int("sent", sent))
+		case sent == or.cfg.BatchSize:
+			// There's probably more waiting, don't sleep.
+			failures = 0
+			wait = 0
+		default:
+			failures = 0
+		}
+
+		timer.Reset(wait)
+	}
+}
+
+// Cleanup deletes, batch by batch, every message sent longer than the retention ago.
+func (or *OutboxRelay) Cleanup(ctx context.Context) (int, error) {
+	sentBefore := time.Now().Add(-or.cfg.Retention)
+	total := 0
+
+	for {
+		deleted, err := or.outboxDB.Cleanup(ctx, sentBefore, or.cfg.BatchSize)
+		total += deleted
+		if err != nil || deleted < or.cfg.BatchSize {
+			return total, err
+		}
+	}
+}
+
+func backoff(base time.Duration, failures int) time.Duration {
+	wait := base
+	for i := 1; i < failures && wait < maxRelayBackoff; i++ {
+		wait *= 2
+	}
+
+	return min(wait, maxRelayBackoff)
+}
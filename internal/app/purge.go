@@ -0,0 +1,95 @@
+package app
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/pkg/logger"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+const purgeErrorSection = "purgeJob"
+
+// purgeSubject is the actor the audit log records for purged companies.
+const purgeSubject = "system:purge"
+
+const (
+	defaultPurgeRetention = 30 * 24 * time.Hour
+	defaultPurgeInterval  = time.Hour
+	defaultPurgeBatchSize = 100
+)
+
+type PurgeConfig struct {
+	// Retention is how long deleted companies stay restorable.
+	Retention time.Duration
+	Interval  time.Duration
+	BatchSize int
+}
+
+// PurgeJob hard deletes, every Interval, the companies deleted for longer than Retention.
+// Several instances may run it at once, each purges different rows.
+type PurgeJob struct {
+	purgeDB domain.CompanyPurgeDB
+	log     *logger.Logger
+	cfg     PurgeConfig
+}
+
+func NewPurgeJob(log *logger.Logger, cfg PurgeConfig, purgeDB domain.CompanyPurgeDB) *PurgeJob {
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaultPurgeRetention
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultPurgeInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultPurgeBatchSize
+	}
+
+	return &PurgeJob{
+		purgeDB: purgeDB,
+		log:     log,
+		cfg:     cfg,
+	}
+}
+
+// Run purges until ctx is done.
+func (pj *PurgeJob) Run(ctx context.Context) {
+	log := pj.log.Named(purgeErrorSection)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		purged, err := pj.Purge(ctx)
+		switch {
+		case err != nil:
+			log.Error("purging deleted companies failed", zap.Error(err), zap.Int("purged", purged))
+		case purged > 0:
+			log.Info(fmt.Sprintf("%d deleted companies purged", purged))
+		}
+
+		timer.Reset(pj.cfg.Interval)
+	}
+}
+
+// Purge removes, batch by batch, every company deleted for longer than the retention.
+func (pj *PurgeJob) Purge(ctx context.Context) (int, error) {
+	ctx = domain.WithPrincipal(ctx, domain.Principal{Subject: purgeSubject})
+	deletedBefore := time.Now().Add(-pj.cfg.Retention)
+	total := 0
+
+	for {
+		purged, err := pj.purgeDB.Purge(ctx, deletedBefore, pj.cfg.BatchSize)
+		total += purged
+		if err != nil || purged < pj.cfg.BatchSize {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// AuditOperation is the kind of change an audit entry records.
+type AuditOperation string
+
+const (
+	AuditCreate  AuditOperation = "create"
+	AuditUpdate  AuditOperation = "update"
+	AuditDelete  AuditOperation = "delete"
+	AuditRestore AuditOperation = "restore"
+	AuditPurge   AuditOperation = "purge"
+	// AuditRevert is an update bringing a company back to one of its revisions.
+	AuditRevert AuditOperation = "revert"
+)
+
+// AuditEntry records a change made to a company, by whom and through which request.
+// Before is absent for creations and After for deletions and purges.
+type AuditEntry struct {
+	ID          int64
+	CompanyID   uuid.UUID
+	CompanyName string
+	Operation   AuditOperation
+	Actor       string
+	RequestID   string
+	Before      json.RawMessage
+	After       json.RawMessage
+	CreatedAt   time.Time
+}
+
+// AuditFilter narrows down the audit log. CompanyName selects the entries of every
+// company that ever had that name, renames included. Zero fields are not applied.
+type AuditFilter struct {
+	CompanyName string
+	From        *time.Time
+	To          *time.Time
+}
+
+// AuditListParams describes one page of the audit log, newest entries first. Cursor is
+// the NextCursor of the previous page, empty for the first one.
+type AuditListParams struct {
+	Filter AuditFilter
+	Limit  int
+	Cursor string
+}
+
+type AuditPage struct {
+	Entries    []AuditEntry
+	NextCursor string
+}
@@ -0,0 +1,42 @@
+package domain
+
+import "github.com/google/uuid"
+
+// BatchOp is the change a batch item makes.
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchPatch  BatchOp = "patch"
+	BatchDelete BatchOp = "delete"
+	// BatchUpsert patches the company with the name when there is one, creates it otherwise.
+	BatchUpsert BatchOp = "upsert"
+)
+
+// BatchItem is one change of a batch. Name is the current name of the company patched,
+// deleted or upserted, Company the company created or the fields patched.
+type BatchItem struct {
+	Op      BatchOp
+	Name    string
+	Company Company
+}
+
+// Batch is a list of changes applied in order, in a single transaction. An atomic batch
+// is all or nothing, otherwise every item is applied unless it fails on its own.
+type Batch struct {
+	Items  []BatchItem
+	Atomic bool
+	// SummaryEvent publishes a single event listing every change instead of one per change.
+	SummaryEvent bool
+	// DryRun rolls the batch back once applied, leaving only its results.
+	DryRun bool
+}
+
+// BatchResult is the outcome of a batch item: the company it changed, or why it wasn't
+// applied.
+type BatchResult struct {
+	ID uuid.UUID
+	// Created tells the companies created from those changed.
+	Created bool
+	Err     error
+}
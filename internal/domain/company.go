@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"fmt"
 	"github.com/google/uuid"
 	"time"
@@ -15,6 +16,16 @@ type Company struct {
 	Type            *CompanyType
 	UpdatedAt       time.Time
 	CreatedAt       time.Time
+	Version         int
+	// DeletedAt is set once the company is deleted, until it is restored or purged.
+	DeletedAt *time.Time
+}
+
+// Precondition makes a mutation conditional on the row still being at the given
+// version, as requested through If-Match. A nil Precondition applies unconditionally.
+type Precondition struct {
+	ID      uuid.UUID
+	Version int
 }
 
 type CompanyType uint8
@@ -56,16 +67,155 @@ func GetCompTypeFromString(compType string) (CompanyType, error) {
 	}
 }
 
+// CompanyDB soft deletes companies: they are hidden from every method but the ones
+// including the deleted ones, and Restore, until purged.
 type CompanyDB interface {
-	Insert(Company) (uuid.UUID, error)
-	DeleteByName(string) error
-	PatchByName(Company, string) error
-	GetByName(string) (Company, error)
+	Insert(context.Context, Company) (uuid.UUID, error)
+	DeleteByName(context.Context, string, *Precondition) error
+	PatchByName(context.Context, Company, string, *Precondition) error
+	GetByName(context.Context, string) (Company, error)
+	// GetByNameIncludingDeleted falls back to the company deleted last with the name.
+	GetByNameIncludingDeleted(context.Context, string) (Company, error)
+	DeleteByID(context.Context, uuid.UUID, *Precondition) error
+	PatchByID(context.Context, Company, uuid.UUID, *Precondition) error
+	GetByID(context.Context, uuid.UUID) (Company, error)
+	GetByIDIncludingDeleted(context.Context, uuid.UUID) (Company, error)
+	// Restore undeletes the company deleted last with the name.
+	Restore(context.Context, string) error
+	List(context.Context, CompanyListParams) (CompanyPage, error)
+	ListAudit(context.Context, AuditListParams) (AuditPage, error)
+	// GetByNameAsOf returns the company that had the name at asOf, as it was then.
+	GetByNameAsOf(ctx context.Context, name string, asOf time.Time) (Company, error)
+	ListRevisions(ctx context.Context, name string, params RevisionListParams) (RevisionPage, error)
+	GetRevision(ctx context.Context, name string, version int) (Revision, error)
+	// Revert updates the company back to the state of one of its revisions.
+	Revert(ctx context.Context, name string, version int, precondition *Precondition) (Company, error)
+	// Batch fails only when the whole batch did, the failures of its items are in their result.
+	Batch(context.Context, Batch) ([]BatchResult, error)
+	// Export calls fn with every company to export, all read from the same snapshot. It stops
+	// at the first error fn returns.
+	Export(ctx context.Context, params CompanyExportParams, fn func(Company) error) error
+	// Search ranks the companies matching the text of their name and description, or
+	// whose name is close to it.
+	Search(context.Context, CompanySearchParams) (CompanySearchPage, error)
 }
 
 type CompanyService interface {
-	Create(Company) (uuid.UUID, error)
-	Delete(string) error
-	Patch(Company, string) error
-	Get(string) (Company, error)
+	Create(context.Context, Company) (uuid.UUID, error)
+	Delete(context.Context, string, *Precondition) error
+	Patch(context.Context, Company, string, *Precondition) error
+	Get(ctx context.Context, name string, includeDeleted bool) (Company, error)
+	DeleteByID(context.Context, uuid.UUID, *Precondition) error
+	PatchByID(context.Context, Company, uuid.UUID, *Precondition) error
+	GetByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (Company, error)
+	Restore(context.Context, string) error
+	List(context.Context, CompanyListParams) (CompanyPage, error)
+	ListAudit(context.Context, AuditListParams) (AuditPage, error)
+	GetAsOf(ctx context.Context, name string, asOf time.Time) (Company, error)
+	ListRevisions(ctx context.Context, name string, params RevisionListParams) (RevisionPage, error)
+	GetRevision(ctx context.Context, name string, version int) (Revision, error)
+	Revert(ctx context.Context, name string, version int, precondition *Precondition) (Company, error)
+	Batch(context.Context, Batch) ([]BatchResult, error)
+	Export(ctx context.Context, params CompanyExportParams, fn func(Company) error) error
+	Search(context.Context, CompanySearchParams) (CompanySearchPage, error)
+}
+
+type CompanySort uint8
+
+const (
+	SortByName CompanySort = iota
+	SortByCreatedAt
+	SortByUpdatedAt
+)
+
+func (cs CompanySort) String() string {
+	switch cs {
+	case SortByName:
+		return "name"
+	case SortByCreatedAt:
+		return "created_at"
+	case SortByUpdatedAt:
+		return "updated_at"
+	default:
+		return ""
+	}
+}
+
+func GetCompSortFromString(sort string) (CompanySort, error) {
+	switch sort {
+	case "name":
+		return SortByName, nil
+	case "created_at":
+		return SortByCreatedAt, nil
+	case "updated_at":
+		return SortByUpdatedAt, nil
+	default:
+		return 0, fmt.Errorf("invalid sort")
+	}
+}
+
+// CompanyFilter narrows down a listing. Nil fields are not applied.
+type CompanyFilter struct {
+	Type          *CompanyType
+	IsRegistered  *bool
+	MinEmployees  *int
+	MaxEmployees  *int
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// IncludeDeleted lists the deleted companies along with the others.
+	IncludeDeleted bool
+}
+
+// CompanyListParams describes one page request. Cursor is the opaque value
+// returned as NextCursor by the previous page, empty for the first page.
+type CompanyListParams struct {
+	Filter     CompanyFilter
+	SortBy     CompanySort
+	Descending bool
+	Limit      int
+	Cursor     string
+}
+
+// CompanyExportParams describes an export: every company matching Filter, in order.
+type CompanyExportParams struct {
+	Filter     CompanyFilter
+	SortBy     CompanySort
+	Descending bool
+}
+
+// CompanySearchParams describes one page of search results. Text is a web search style
+// query: words, "quoted phrases", or and -excluded words.
+type CompanySearchParams struct {
+	Text         string
+	Type         *CompanyType
+	IsRegistered *bool
+	Limit        int
+	Cursor       string
+}
+
+// CompanySearchResult is a company found by a search. NameHighlight and Snippet, an excerpt
+// of the description, are HTML escaped and wrap the words matched in <mark> tags.
+type CompanySearchResult struct {
+	Company       Company
+	Rank          float64
+	NameHighlight string
+	Snippet       string
+}
+
+type CompanySearchPage struct {
+	Results    []CompanySearchResult
+	NextCursor string
+}
+
+type CompanyPage struct {
+	Companies  []Company
+	NextCursor string
+}
+
+// CompanyPurgeDB hard deletes the companies deleted for long enough.
+type CompanyPurgeDB interface {
+	// Purge removes up to limit companies deleted before deletedBefore and returns how many.
+	Purge(ctx context.Context, deletedBefore time.Time, limit int) (int, error)
 }
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord is the outcome of the first request made with an Idempotency-Key.
+// StatusCode is zero while that request is still being processed.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+type IdempotencyDB interface {
+	// Reserve claims key for a new request. When the key is already held by an unexpired
+	// record it returns that record and false instead.
+	Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, key string, statusCode int, headers map[string]string, body []byte) error
+	Release(ctx context.Context, key string) error
+}
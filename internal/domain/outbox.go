@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+// OutboxMessage is an event recorded in the same transaction as the change it describes,
+// waiting to be published. AggregateID is used as the message key.
+type OutboxMessage struct {
+	ID          int64
+	AggregateID uuid.UUID
+	EventType   string
+	Headers     map[string]string
+	Payload     []byte
+	Attempts    int
+	CreatedAt   time.Time
+}
+
+type OutboxDB interface {
+	// Relay claims the oldest unsent messages, up to limit, for lease and hands them to
+	// publish in order, outside of any transaction, then marks the published ones sent. It
+	// stops at the first publish error, records it on the message and returns it, so that
+	// ordering is kept. Nothing is claimed while another relay's claim hasn't expired. It
+	// returns how many were sent.
+	Relay(ctx context.Context, limit int, lease time.Duration, publish func(context.Context, OutboxMessage) error) (int, error)
+	// Cleanup deletes up to limit messages sent before sentBefore and returns how many.
+	Cleanup(ctx context.Context, sentBefore time.Time, limit int) (int, error)
+}
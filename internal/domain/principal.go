@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"context"
+	"slices"
+)
+
+// Scopes grant access to the companies API.
+const (
+	ScopeCompaniesRead   = "companies:read"
+	ScopeCompaniesWrite  = "companies:write"
+	ScopeCompaniesDelete = "companies:delete"
+	ScopeAuditRead       = "audit:read"
+	// ScopeCompaniesAdmin grants access to the deleted companies.
+	ScopeCompaniesAdmin = "companies:admin"
+)
+
+// Principal is who a request is made on behalf of, as authenticated from its token.
+type Principal struct {
+	Subject string
+	Scopes  []string
+}
+
+// HasScope reports whether the principal was granted scope.
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal carried by ctx, if any.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+
+	return p, ok
+}
@@ -0,0 +1,21 @@
+package domain
+
+// Revision is the state a change left a company in. Every change writes one, numbered by
+// the version the company reached, and revisions are kept even once the company is purged.
+// Company.UpdatedAt is when the revision was made.
+type Revision struct {
+	Operation AuditOperation
+	Company   Company
+}
+
+// RevisionListParams describes one page of the revisions of a company, newest first.
+// Cursor is the NextCursor of the previous page, empty for the first one.
+type RevisionListParams struct {
+	Limit  int
+	Cursor string
+}
+
+type RevisionPage struct {
+	Revisions  []Revision
+	NextCursor string
+}
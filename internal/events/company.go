@@ -0,0 +1,179 @@
+// Package events defines the messages published on the company mutations topic. They
+// follow the CloudEvents 1.0 JSON format (structured mode), documented in api/asyncapi.yaml.
+package events
+
+import (
+	"company-crud/internal/domain"
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	SpecVersion = "1.0"
+	// SchemaVersion is bumped whenever Data changes in a non backwards compatible way.
+	SchemaVersion   = "1"
+	Source          = "company-crud"
+	DataContentType = "application/json"
+	ContentType     = "application/cloudevents+json; charset=UTF-8"
+)
+
+type Type string
+
+const (
+	CompanyCreated  Type = "company.created"
+	CompanyUpdated  Type = "company.updated"
+	CompanyDeleted  Type = "company.deleted"
+	CompanyRestored Type = "company.restored"
+	// CompanyBatch sums up the changes of a batch, instead of one event per change.
+	CompanyBatch Type = "company.batch"
+)
+
+// Company is the snapshot of a company carried by every event.
+type Company struct {
+	ID              uuid.UUID `json:"id"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	EmployeesNumber int       `json:"amount_of_employees"`
+	IsRegistered    bool      `json:"registered"`
+	Type            string    `json:"type"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Version         int       `json:"version"`
+	// DeletedAt is set on the deleted companies.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Data holds the company as it is after the change, or as it was right before being
+// deleted. Updates and restores also carry the state prior to the change in Before.
+type Data struct {
+	Company Company  `json:"company"`
+	Before  *Company `json:"before,omitempty"`
+}
+
+// Change is one of the changes of a batch, as a single event would have carried it.
+type Change struct {
+	Type    Type     `json:"type"`
+	Company Company  `json:"company"`
+	Before  *Company `json:"before,omitempty"`
+}
+
+// BatchData lists the changes of a batch, in the order they were made.
+type BatchData struct {
+	Changes []Change `json:"changes"`
+}
+
+// Envelope is the CloudEvents envelope around the data of every event.
+type Envelope[T any] struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              uuid.UUID `json:"id"`
+	Type            Type      `json:"type"`
+	Source          string    `json:"source"`
+	Subject         string    `json:"subject"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	SchemaVersion   string    `json:"schemaVersion"`
+	Data            T         `json:"data"`
+}
+
+// Event is about the change of one company, which is its subject.
+type Event = Envelope[Data]
+
+// BatchEvent is about the changes of a batch, whose id is its subject.
+type BatchEvent = Envelope[BatchData]
+
+// New builds an event about company. before is only expected for CompanyUpdated and
+// CompanyRestored.
+func New(eventType Type, company domain.Company, before *domain.Company) Event {
+	data := Data{
+		Company: Snapshot(company),
+	}
+	if before != nil {
+		b := Snapshot(*before)
+		data.Before = &b
+	}
+
+	return newEnvelope(eventType, company.ID.String(), data)
+}
+
+// NewChange builds the entry of a batch event about company, like New would.
+func NewChange(eventType Type, company domain.Company, before *domain.Company) Change {
+	change := Change{
+		Type:    eventType,
+		Company: Snapshot(company),
+	}
+	if before != nil {
+		b := Snapshot(*before)
+		change.Before = &b
+	}
+
+	return change
+}
+
+// NewBatch builds the event summing up the changes of the batch batchID.
+func NewBatch(batchID uuid.UUID, changes []Change) BatchEvent {
+	return newEnvelope(CompanyBatch, batchID.String(), BatchData{Changes: changes})
+}
+
+func newEnvelope[T any](eventType Type, subject string, data T) Envelope[T] {
+	return Envelope[T]{
+		SpecVersion:     SpecVersion,
+		ID:              uuid.New(),
+		Type:            eventType,
+		Source:          Source,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: DataContentType,
+		SchemaVersion:   SchemaVersion,
+		Data:            data,
+	}
+}
+
+// Key is the message key, which keeps all the events of a company on the same partition.
+func (e Envelope[T]) Key() []byte {
+	return []byte(e.Subject)
+}
+
+// Headers lets consumers route on the event without decoding the payload.
+func (e Envelope[T]) Headers() map[string]string {
+	return map[string]string{
+		"content-type":     ContentType,
+		"ce_specversion":   e.SpecVersion,
+		"ce_id":            e.ID.String(),
+		"ce_type":          string(e.Type),
+		"ce_source":        e.Source,
+		"ce_subject":       e.Subject,
+		"ce_schemaversion": e.SchemaVersion,
+	}
+}
+
+func (e Envelope[T]) Marshal() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// Snapshot is the state of company as events, and the audit log, record it.
+func Snapshot(company domain.Company) Company {
+	s := Company{
+		ID:        company.ID,
+		Name:      company.Name,
+		CreatedAt: company.CreatedAt,
+		UpdatedAt: company.UpdatedAt,
+		Version:   company.Version,
+		DeletedAt: company.DeletedAt,
+	}
+
+	if company.Description != nil {
+		s.Description = *company.Description
+	}
+	if company.EmployeesNumber != nil {
+		s.EmployeesNumber = *company.EmployeesNumber
+	}
+	if company.IsRegistered != nil {
+		s.IsRegistered = *company.IsRegistered
+	}
+	if company.Type != nil {
+		s.Type = company.Type.String()
+	}
+
+	return s
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"company-crud/internal/domain"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// @Summary      Audit log of a company
+// @Description  Changes made to every company that ever had the name, newest first.
+// @Tags         audit
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        company_name	path	string true "company_name"
+// @Param        from			query	string	false	"RFC 3339 timestamp, inclusive"
+// @Param        to				query	string	false	"RFC 3339 timestamp, exclusive"
+// @Param        limit			query	int		false	"page size, 1-100"
+// @Param        cursor			query	string	false	"next_cursor of the previous page"
+// @Success      200	{object}  AuditList
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
+// @Router       /companies/{company_name}/audit [get]
+func (c *Company) companyAudit(w http.ResponseWriter, r *http.Request) {
+	params, err := auditParamsFromQuery(r.URL.Query())
+	if err != nil {
+		c.writeError(w, r, audit, paramError{err})
+		return
+	}
+	params.Filter.CompanyName = mux.Vars(r)["company_name"]
+
+	c.writeAudit(w, r, params)
+}
+
+// @Summary      Audit log
+// @Description  Changes made to any company, newest first.
+// @Tags         audit
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        from			query	string	false	"RFC 3339 timestamp, inclusive"
+// @Param        to				query	string	false	"RFC 3339 timestamp, exclusive"
+// @Param        limit			query	int		false	"page size, 1-100"
+// @Param        cursor			query	string	false	"next_cursor of the previous page"
+// @Success      200	{object}  AuditList
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
+// @Router       /audit [get]
+func (c *Company) audit(w http.ResponseWriter, r *http.Request) {
+	params, err := auditParamsFromQuery(r.URL.Query())
+	if err != nil {
+		c.writeError(w, r, audit, paramError{err})
+		return
+	}
+
+	c.writeAudit(w, r, params)
+}
+
+func (c *Company) writeAudit(w http.ResponseWriter, r *http.Request, params domain.AuditListParams) {
+	w.Header().Set("Content-Type", "application/json")
+
+	result, err := c.companyService.ListAudit(r.Context(), params)
+	if err != nil {
+		c.writeError(w, r, audit, err)
+		return
+	}
+
+	entries := make([]AuditEntry, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		entries = append(entries, auditConverter(entry))
+	}
+
+	response, err := json.Marshal(AuditList{
+		Entries:    entries,
+		NextCursor: result.NextCursor,
+	})
+	if err != nil {
+		c.writeError(w, r, audit, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(response)
+}
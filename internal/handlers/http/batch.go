@@ -0,0 +1,160 @@
+package http
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/pkg/postres"
+	"company-crud/pkg/validator"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// @Summary      Apply a batch of changes
+// @Description  Creates, patches and deletes companies in order, in one transaction. An atomic batch is all or nothing: it answers 200, or the status of the operation that failed while the others are 424. Otherwise every operation succeeds or fails on its own and the batch answers 207.
+// @Tags         company
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        batch	body	BatchRequest  true  "batch"
+// @Param        Idempotency-Key	header	string false "retries with the same key and body replay the first response"
+// @Success      200	{object}  BatchResponse
+// @Success      207	{object}  BatchResponse
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      404	{object}  BatchResponse
+// @Failure      409	{object}  BatchResponse
+// @Failure      422	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
+// @Router       /companies:batch [post]
+func (c *Company) batch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	reqData := BatchRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(&reqData)
+	if err != nil {
+		c.writeError(w, r, batch, decodeError{err})
+		return
+	}
+
+	if err := c.validator.Struct(reqData); err != nil {
+		c.writeError(w, r, batch, err)
+		return
+	}
+
+	summaryEvent := c.cfg.BatchSummaryEvent
+	switch reqData.Events {
+	case batchEventsItem:
+		summaryEvent = false
+	case batchEventsSummary:
+		summaryEvent = true
+	}
+
+	items := make([]domain.BatchItem, len(reqData.Operations))
+	results := make([]domain.BatchResult, len(reqData.Operations))
+	var valid []domain.BatchItem
+	var validIndexes []int
+	for i, operation := range reqData.Operations {
+		items[i], results[i].Err = c.batchItem(operation)
+		if results[i].Err == nil {
+			valid = append(valid, items[i])
+			validIndexes = append(validIndexes, i)
+		}
+
+		if items[i].Op == domain.BatchDelete {
+			if err := requireScope(r.Context(), domain.ScopeCompaniesDelete); err != nil {
+				c.writeError(w, r, batch, err)
+				return
+			}
+		}
+	}
+
+	switch {
+	case reqData.Atomic && len(valid) < len(items):
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = postres.NotApplied
+			}
+		}
+	case len(valid) > 0:
+		applied, err := c.companyService.Batch(r.Context(), domain.Batch{
+			Items:        valid,
+			Atomic:       reqData.Atomic,
+			SummaryEvent: summaryEvent,
+		})
+		if err != nil {
+			c.writeError(w, r, batch, err)
+			return
+		}
+
+		for j, i := range validIndexes {
+			results[i] = applied[j]
+		}
+	}
+
+	status := http.StatusMultiStatus
+	if reqData.Atomic {
+		status = http.StatusOK
+	}
+
+	response := BatchResponse{Results: make([]BatchResult, 0, len(results))}
+	for i, result := range results {
+		if result.Err != nil {
+			problem := problemFromError(result.Err)
+			if reqData.Atomic && status == http.StatusOK && !errors.Is(result.Err, postres.NotApplied) {
+				status = problem.Status
+			}
+
+			response.Results = append(response.Results, BatchResult{Status: problem.Status, Error: &problem})
+			continue
+		}
+
+		id := result.ID
+		itemStatus := http.StatusOK
+		if items[i].Op == domain.BatchCreate {
+			itemStatus = http.StatusCreated
+		}
+		response.Results = append(response.Results, BatchResult{Status: itemStatus, ID: &id})
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		c.writeError(w, r, batch, err)
+		return
+	}
+
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// batchItem validates an operation of a batch, as the endpoint doing it alone would.
+func (c *Company) batchItem(operation BatchOperation) (domain.BatchItem, error) {
+	item := domain.BatchItem{
+		Op:   domain.BatchOp(operation.Op),
+		Name: operation.Name,
+	}
+
+	if item.Op != domain.BatchCreate && item.Name == "" {
+		return item, validator.NewFieldError("name", "required", "is required")
+	}
+
+	var err error
+	switch item.Op {
+	case domain.BatchCreate:
+		reqData := Create{}
+		if err := json.Unmarshal(operation.Data, &reqData); err != nil {
+			return item, decodeError{err}
+		}
+		item.Company, err = c.createToCompany(reqData)
+	case domain.BatchPatch:
+		reqData := Patch{}
+		if err := json.Unmarshal(operation.Data, &reqData); err != nil {
+			return item, decodeError{err}
+		}
+		item.Company, err = c.patchToCompany(reqData)
+	}
+
+	return item, err
+}
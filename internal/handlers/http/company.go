@@ -3,13 +3,15 @@ package http
 import (
 	"company-crud/internal/domain"
 	"company-crud/pkg/logger"
-	"company-crud/pkg/postres"
 	"company-crud/pkg/validator"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
 	"net/http"
+	"time"
 )
 
 const errorSection = "companyHandler"
@@ -18,43 +20,133 @@ const (
 	deleteM = "deleteM"
 	patch   = "patch"
 	get     = "get"
+	list    = "list"
+	audit   = "audit"
+	restore = "restore"
+
+	revisions = "revisions"
+	revision  = "revision"
+	revert    = "revert"
+	batch     = "batch"
+	importM   = "import"
+	exportM   = "export"
+	search    = "search"
+
+	getByID    = "getByID"
+	deleteByID = "deleteByID"
+	patchByID  = "patchByID"
 )
 
+type Config struct {
+	// TokenKeys selects the key verifying the signature of a token.
+	TokenKeys jwt.Keyfunc
+	// TokenIssuer and TokenAudience, when set, must match the iss and aud claims of tokens.
+	TokenIssuer   string
+	TokenAudience string
+	// LegacyTokenHeader accepts the token in the Token header, when there is no
+	// Authorization header.
+	LegacyTokenHeader bool
+	IdempotencyTTL    time.Duration
+	RequestTimeout    time.Duration
+	// BatchSummaryEvent publishes a single event per batch, unless the batch asks otherwise.
+	BatchSummaryEvent bool
+}
+
 type Company struct {
 	logger         *logger.Logger
 	validator      *validator.Validator
 	companyService domain.CompanyService
-	tokenSignature string
+	idempotencyDB  domain.IdempotencyDB
+	cfg            Config
 }
 
-func New(log *logger.Logger, cs domain.CompanyService, tokenSig string) *Company {
+func New(log *logger.Logger, cs domain.CompanyService, idempotencyDB domain.IdempotencyDB, cfg Config) *Company {
 	return &Company{
 		logger:         log,
 		validator:      validator.New(),
 		companyService: cs,
-		tokenSignature: tokenSig,
+		idempotencyDB:  idempotencyDB,
+		cfg:            cfg,
+	}
+}
+
+// reservedNames are the paths of the fixed routes under /companies. A company named after
+// one could be created but never reached by its name.
+var reservedNames = map[string]bool{
+	"import": true,
+	"export": true,
+	"search": true,
+}
+
+// checkName rejects the names of companies that couldn't be reached by name.
+func checkName(name string) error {
+	if reservedNames[name] {
+		return validator.NewFieldError("name", "reserved", "is reserved")
 	}
+
+	return nil
 }
 
 func (c *Company) AddRoute(r *mux.Router) {
+	read := authorize(domain.ScopeCompaniesRead)
+	write := authorize(domain.ScopeCompaniesWrite)
+	remove := authorize(domain.ScopeCompaniesDelete)
+	readAudit := authorize(domain.ScopeAuditRead)
+
+	// Registered first, /companies would otherwise take it for a prefix.
+	batchRoutes := r.Path("/companies:batch").Subrouter()
+	batchRoutes.Use(traced(otel.Tracer(tracerName)), withTimeout(c.cfg.RequestTimeout), authenticate(c.cfg))
+	batchRoutes.Handle("", write(idempotent(c.idempotencyDB, c.cfg.IdempotencyTTL, c.logger)(http.HandlerFunc(c.batch)))).Methods(http.MethodPost)
+
+	// Imports and exports take as long as their file, they only end with the client.
+	importRoutes := r.Path("/companies/import").Subrouter()
+	importRoutes.Use(traced(otel.Tracer(tracerName)), authenticate(c.cfg))
+	importRoutes.Handle("", write(http.HandlerFunc(c.importCompanies))).Methods(http.MethodPost)
+
+	exportRoutes := r.Path("/companies/export").Subrouter()
+	exportRoutes.Use(traced(otel.Tracer(tracerName)), authenticate(c.cfg))
+	exportRoutes.Handle("", read(http.HandlerFunc(c.export))).Methods(http.MethodGet)
+
 	companiesRoutes := r.PathPrefix("/companies").Subrouter()
-	companiesRoutes.Use(validateToken(c.tokenSignature))
-	companiesRoutes.HandleFunc("", c.create).Methods(http.MethodPost)
-	companiesRoutes.HandleFunc("/{company_name}", c.get).Methods(http.MethodGet)
-	companiesRoutes.HandleFunc("/{company_name}", c.delete).Methods(http.MethodDelete)
-	companiesRoutes.HandleFunc("/{company_name}", c.patch).Methods(http.MethodPatch)
+	companiesRoutes.Use(traced(otel.Tracer(tracerName)), withTimeout(c.cfg.RequestTimeout), authenticate(c.cfg))
+
+	companiesRoutes.Handle("", write(idempotent(c.idempotencyDB, c.cfg.IdempotencyTTL, c.logger)(http.HandlerFunc(c.create)))).Methods(http.MethodPost)
+	companiesRoutes.Handle("", read(http.HandlerFunc(c.list))).Methods(http.MethodGet)
+	companiesRoutes.Handle("/search", read(http.HandlerFunc(c.search))).Methods(http.MethodGet)
+	companiesRoutes.Handle("/id/{id}", read(http.HandlerFunc(c.getByID))).Methods(http.MethodGet)
+	companiesRoutes.Handle("/id/{id}", remove(http.HandlerFunc(c.deleteByID))).Methods(http.MethodDelete)
+	companiesRoutes.Handle("/id/{id}", write(http.HandlerFunc(c.patchByID))).Methods(http.MethodPatch)
+	companiesRoutes.Handle("/{company_name}/audit", readAudit(http.HandlerFunc(c.companyAudit))).Methods(http.MethodGet)
+	companiesRoutes.Handle("/{company_name}/restore", remove(http.HandlerFunc(c.restore))).Methods(http.MethodPost)
+	companiesRoutes.Handle("/{company_name}/revisions", read(http.HandlerFunc(c.revisions))).Methods(http.MethodGet)
+	companiesRoutes.Handle("/{company_name}/revisions/{version}", read(http.HandlerFunc(c.revision))).Methods(http.MethodGet)
+	companiesRoutes.Handle("/{company_name}/revisions/{version}/revert", write(http.HandlerFunc(c.revert))).Methods(http.MethodPost)
+	companiesRoutes.Handle("/{company_name}", read(http.HandlerFunc(c.get))).Methods(http.MethodGet)
+	companiesRoutes.Handle("/{company_name}", remove(http.HandlerFunc(c.delete))).Methods(http.MethodDelete)
+	companiesRoutes.Handle("/{company_name}", write(http.HandlerFunc(c.patch))).Methods(http.MethodPatch)
+
+	auditRoutes := r.PathPrefix("/audit").Subrouter()
+	auditRoutes.Use(traced(otel.Tracer(tracerName)), withTimeout(c.cfg.RequestTimeout), authenticate(c.cfg))
+	auditRoutes.Handle("", readAudit(http.HandlerFunc(c.audit))).Methods(http.MethodGet)
 }
 
 // @Summary      Create new company
 // @Tags         company
 // @Accept       json
 // @Produce      json
-// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Param        createCompany	body	Create  true  "createCompany"
-// @Success      200
-// @Failure      400
-// @Failure      406
-// @Failure      409	{object}  Error true
+// @Param        Idempotency-Key	header	string false "retries with the same key and body replay the first response"
+// @Success      201	{object}  Created
+// @Header       201	{string}  Location	"/companies/id/{id}"
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      409	{object}  Problem
+// @Failure      422	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
 // @Router       /companies [post]
 func (c *Company) create(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -62,101 +154,195 @@ func (c *Company) create(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&reqData)
 	if err != nil {
-		c.logger.Named(fmt.Sprintf("%s:%s", errorSection, create)).Debug(err.Error())
-		w.WriteHeader(http.StatusBadRequest)
+		c.writeError(w, r, create, decodeError{err})
 		return
 	}
 
-	if err := c.validator.Struct(reqData); err != nil {
-		c.logger.Named(fmt.Sprintf("%s:%s", errorSection, create)).Debug(err.Error())
-		w.WriteHeader(http.StatusNotAcceptable)
+	company, err := c.createToCompany(reqData)
+	if err != nil {
+		c.writeError(w, r, create, err)
 		return
 	}
 
-	companyType, err := domain.GetCompTypeFromString(reqData.Type)
+	id, err := c.companyService.Create(r.Context(), company)
 	if err != nil {
-		c.logger.Named(fmt.Sprintf("%s:%s", errorSection, create)).Debug(err.Error())
-		w.WriteHeader(http.StatusNotAcceptable)
+		c.writeError(w, r, create, err)
 		return
 	}
 
-	_, err = c.companyService.Create(domain.Company{
-		Name:            reqData.Name,
-		Description:     &reqData.Description,
-		EmployeesNumber: reqData.EmployeesNumber,
-		IsRegistered:    &reqData.IsRegistered,
-		Type:            &companyType,
+	response, err := json.Marshal(Created{
+		ID: id,
 	})
 	if err != nil {
-		c.logger.Named(fmt.Sprintf("%s:%s", errorSection, create)).Debug(err.Error())
-
-		if errors.Is(err, postres.DuplicateKey) {
-			w.WriteHeader(http.StatusConflict)
-			resp, _ := json.Marshal(Error{
-				Message: "duplicate name",
-			})
-			w.Write(resp)
-			return
-		}
-
-		w.WriteHeader(http.StatusNotAcceptable)
+		c.writeError(w, r, create, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Location", fmt.Sprintf("/companies/id/%s", id))
+	w.WriteHeader(http.StatusCreated)
+	w.Write(response)
 }
 
 // @Summary      Get company
 // @Tags         company
 // @Accept       json
 // @Produce      json
-// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Param        company_name	path	string true "company_name"
-// @Success      200
-// @Failure      400
-// @Failure      406
-// @Failure      409	{object}  Error true
-// @Failure      500
+// @Param        include_deleted	query	bool	false	"falls back to the company deleted last with the name, needs companies:admin"
+// @Param        as_of	query	string	false	"RFC 3339 timestamp, returns the company that had the name then, as it was"
+// @Param        If-None-Match	header	string false "ETag of a cached representation"
+// @Success      200	{object}  Get
+// @Header       200	{string}  ETag	"current revision of the company"
+// @Success      304
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      404	{object}  Problem
+// @Failure      422	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
 // @Router       /companies/{company_name} [get]
 func (c *Company) get(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	nameParam := mux.Vars(r)["company_name"]
 
-	if nameParam == "" {
-		c.logger.Named(fmt.Sprintf("%s:%s", errorSection, get)).Debug("company_name param can't be empty")
-		w.WriteHeader(http.StatusNotAcceptable)
+	nameParam, err := nameParam(r)
+	if err != nil {
+		c.writeError(w, r, get, err)
+		return
+	}
+
+	asOf, err := timeParam(r.URL.Query(), "as_of")
+	if err != nil {
+		c.writeError(w, r, get, paramError{err})
+		return
+	}
+
+	includeDeleted, err := c.includeDeleted(r)
+	if err != nil {
+		c.writeError(w, r, get, err)
+		return
+	}
+
+	var result domain.Company
+	switch {
+	case asOf != nil && includeDeleted:
+		err = paramError{fmt.Errorf("as_of and include_deleted can't be combined")}
+	case asOf != nil:
+		result, err = c.companyService.GetAsOf(r.Context(), nameParam, *asOf)
+	default:
+		result, err = c.companyService.Get(r.Context(), nameParam, includeDeleted)
+	}
+	if err != nil {
+		c.writeError(w, r, get, err)
+		return
+	}
+
+	c.writeCompany(w, r, get, result)
+}
+
+// @Summary      Get company by id
+// @Tags         company
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        id	path	string true "company id"
+// @Param        include_deleted	query	bool	false	"also find the company when deleted, needs companies:admin"
+// @Param        If-None-Match	header	string false "ETag of a cached representation"
+// @Success      200	{object}  Get
+// @Header       200	{string}  ETag	"current revision of the company"
+// @Success      304
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      404	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
+// @Router       /companies/id/{id} [get]
+func (c *Company) getByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := idParam(r)
+	if err != nil {
+		c.writeError(w, r, getByID, err)
 		return
 	}
 
-	result, err := c.companyService.Get(nameParam)
+	includeDeleted, err := c.includeDeleted(r)
 	if err != nil {
-		c.logger.Named(fmt.Sprintf("%s:%s", errorSection, get)).Debug(err.Error())
+		c.writeError(w, r, getByID, err)
+		return
+	}
 
-		if errors.Is(err, postres.NoRowsErr) {
-			w.WriteHeader(http.StatusConflict)
-			resp, _ := json.Marshal(Error{
-				Message: "no results",
-			})
-			w.Write(resp)
+	result, err := c.companyService.GetByID(r.Context(), id, includeDeleted)
+	if err != nil {
+		c.writeError(w, r, getByID, err)
+		return
+	}
+
+	c.writeCompany(w, r, getByID, result)
+}
+
+// @Summary      List companies
+// @Tags         company
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        type			query	string	false	"company type"
+// @Param        registered		query	bool	false	"registered"
+// @Param        include_deleted	query	bool	false	"list the deleted companies too, needs companies:admin"
+// @Param        min_employees	query	int		false	"minimum amount of employees"
+// @Param        max_employees	query	int		false	"maximum amount of employees"
+// @Param        created_after	query	string	false	"RFC 3339 timestamp, inclusive"
+// @Param        created_before	query	string	false	"RFC 3339 timestamp, exclusive"
+// @Param        updated_after	query	string	false	"RFC 3339 timestamp, inclusive"
+// @Param        updated_before	query	string	false	"RFC 3339 timestamp, exclusive"
+// @Param        sort			query	string	false	"name, created_at or updated_at"
+// @Param        order			query	string	false	"asc or desc"
+// @Param        limit			query	int		false	"page size, 1-100"
+// @Param        cursor			query	string	false	"next_cursor of the previous page"
+// @Success      200	{object}  List
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
+// @Router       /companies [get]
+func (c *Company) list(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	params, err := listParamsFromQuery(r.URL.Query())
+	if err != nil {
+		c.writeError(w, r, list, paramError{err})
+		return
+	}
+	if params.Filter.IncludeDeleted {
+		if err := requireScope(r.Context(), domain.ScopeCompaniesAdmin); err != nil {
+			c.writeError(w, r, list, err)
 			return
 		}
+	}
 
-		w.WriteHeader(http.StatusBadRequest)
+	result, err := c.companyService.List(r.Context(), params)
+	if err != nil {
+		c.writeError(w, r, list, err)
 		return
 	}
 
-	response, err := json.Marshal(Get{
-		ID:              result.ID,
-		Name:            result.Name,
-		Description:     *result.Description,
-		EmployeesNumber: *result.EmployeesNumber,
-		IsRegistered:    *result.IsRegistered,
-		Type:            result.Type.String(),
-		UpdatedAt:       result.UpdatedAt,
-		CreatedAt:       result.CreatedAt,
+	companies := make([]Get, 0, len(result.Companies))
+	for _, company := range result.Companies {
+		companies = append(companies, getConverter(company))
+	}
+
+	response, err := json.Marshal(List{
+		Companies:  companies,
+		NextCursor: result.NextCursor,
 	})
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		c.writeError(w, r, list, err)
 		return
 	}
 
@@ -168,38 +354,82 @@ func (c *Company) get(w http.ResponseWriter, r *http.Request) {
 // @Tags         company
 // @Accept       json
 // @Produce      json
-// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Param        company_name	path	string true "company_name"
+// @Param        If-Match	header	string false "ETag the company is expected to be at"
 // @Success      200
-// @Failure      400
-// @Failure      406
-// @Failure      409	{object}  Error true
-// @Failure      500
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      404	{object}  Problem
+// @Failure      412	{object}  Problem
+// @Failure      422	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
 // @Router       /companies/{company_name} [delete]
 func (c *Company) delete(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	nameParam := mux.Vars(r)["company_name"]
 
-	if nameParam == "" {
-		c.logger.Named(fmt.Sprintf("%s:%s", errorSection, deleteM)).Debug("company_name param can't be empty")
-		w.WriteHeader(http.StatusNotAcceptable)
+	nameParam, err := nameParam(r)
+	if err != nil {
+		c.writeError(w, r, deleteM, err)
 		return
 	}
 
-	err := c.companyService.Delete(nameParam)
+	precondition, err := ifMatch(r, func() (domain.Company, error) {
+		return c.companyService.Get(r.Context(), nameParam, false)
+	})
 	if err != nil {
-		c.logger.Named(fmt.Sprintf("%s:%s", errorSection, deleteM)).Debug(err.Error())
+		c.writeError(w, r, deleteM, err)
+		return
+	}
 
-		if errors.Is(err, postres.NoRowsErr) {
-			w.WriteHeader(http.StatusConflict)
-			resp, _ := json.Marshal(Error{
-				Message: "no entries affected",
-			})
-			w.Write(resp)
-			return
-		}
+	err = c.companyService.Delete(r.Context(), nameParam, precondition)
+	if err != nil {
+		c.writeError(w, r, deleteM, err)
+		return
+	}
 
-		w.WriteHeader(http.StatusBadRequest)
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary      Delete company by id
+// @Tags         company
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        id	path	string true "company id"
+// @Param        If-Match	header	string false "ETag the company is expected to be at"
+// @Success      200
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      404	{object}  Problem
+// @Failure      412	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
+// @Router       /companies/id/{id} [delete]
+func (c *Company) deleteByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := idParam(r)
+	if err != nil {
+		c.writeError(w, r, deleteByID, err)
+		return
+	}
+
+	precondition, err := ifMatch(r, func() (domain.Company, error) {
+		return c.companyService.GetByID(r.Context(), id, false)
+	})
+	if err != nil {
+		c.writeError(w, r, deleteByID, err)
+		return
+	}
+
+	err = c.companyService.DeleteByID(r.Context(), id, precondition)
+	if err != nil {
+		c.writeError(w, r, deleteByID, err)
 		return
 	}
 
@@ -210,37 +440,231 @@ func (c *Company) delete(w http.ResponseWriter, r *http.Request) {
 // @Tags         company
 // @Accept       json
 // @Produce      json
-// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Param        company_name	path	string true "company_name"
 // @Param        patchCompany	body	Patch  true  "patchCompany"
+// @Param        If-Match	header	string false "ETag the company is expected to be at"
 // @Success      200
-// @Failure      400
-// @Failure      406
-// @Failure      409	{object}  Error true
-// @Failure      500
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      404	{object}  Problem
+// @Failure      409	{object}  Problem
+// @Failure      412	{object}  Problem
+// @Failure      422	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
 // @Router       /companies/{company_name} [patch]
 func (c *Company) patch(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	nameParam := mux.Vars(r)["company_name"]
 
-	if nameParam == "" {
-		c.logger.Named(fmt.Sprintf("%s:%s", errorSection, patch)).Debug("company_name param can't be empty")
-		w.WriteHeader(http.StatusNotAcceptable)
+	nameParam, err := nameParam(r)
+	if err != nil {
+		c.writeError(w, r, patch, err)
 		return
 	}
 
+	companyPatch, err := c.decodePatch(r)
+	if err != nil {
+		c.writeError(w, r, patch, err)
+		return
+	}
+
+	precondition, err := ifMatch(r, func() (domain.Company, error) {
+		return c.companyService.Get(r.Context(), nameParam, false)
+	})
+	if err != nil {
+		c.writeError(w, r, patch, err)
+		return
+	}
+
+	err = c.companyService.Patch(r.Context(), companyPatch, nameParam, precondition)
+	if err != nil {
+		c.writeError(w, r, patch, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary      Patch company by id
+// @Tags         company
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        id	path	string true "company id"
+// @Param        patchCompany	body	Patch  true  "patchCompany"
+// @Param        If-Match	header	string false "ETag the company is expected to be at"
+// @Success      200
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      404	{object}  Problem
+// @Failure      409	{object}  Problem
+// @Failure      412	{object}  Problem
+// @Failure      422	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
+// @Router       /companies/id/{id} [patch]
+func (c *Company) patchByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := idParam(r)
+	if err != nil {
+		c.writeError(w, r, patchByID, err)
+		return
+	}
+
+	companyPatch, err := c.decodePatch(r)
+	if err != nil {
+		c.writeError(w, r, patchByID, err)
+		return
+	}
+
+	precondition, err := ifMatch(r, func() (domain.Company, error) {
+		return c.companyService.GetByID(r.Context(), id, false)
+	})
+	if err != nil {
+		c.writeError(w, r, patchByID, err)
+		return
+	}
+
+	err = c.companyService.PatchByID(r.Context(), companyPatch, id, precondition)
+	if err != nil {
+		c.writeError(w, r, patchByID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary      Restore company
+// @Description  Undeletes the company deleted last with the name, unless it was purged or the name was taken since.
+// @Tags         company
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        company_name	path	string true "company_name"
+// @Success      200	{object}  Get
+// @Header       200	{string}  ETag	"current revision of the company"
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      404	{object}  Problem
+// @Failure      409	{object}  Problem
+// @Failure      422	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
+// @Router       /companies/{company_name}/restore [post]
+func (c *Company) restore(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	nameParam, err := nameParam(r)
+	if err != nil {
+		c.writeError(w, r, restore, err)
+		return
+	}
+
+	err = c.companyService.Restore(r.Context(), nameParam)
+	if err != nil {
+		c.writeError(w, r, restore, err)
+		return
+	}
+
+	result, err := c.companyService.Get(r.Context(), nameParam, false)
+	if err != nil {
+		c.writeError(w, r, restore, err)
+		return
+	}
+
+	c.writeCompany(w, r, restore, result)
+}
+
+// includeDeleted reads the include_deleted parameter, only admins may set it.
+func (c *Company) includeDeleted(r *http.Request) (bool, error) {
+	includeDeleted, err := boolParam(r.URL.Query(), "include_deleted")
+	if err != nil {
+		return false, paramError{err}
+	}
+	if includeDeleted {
+		if err := requireScope(r.Context(), domain.ScopeCompaniesAdmin); err != nil {
+			return false, err
+		}
+	}
+
+	return includeDeleted, nil
+}
+
+func nameParam(r *http.Request) (string, error) {
+	name := mux.Vars(r)["company_name"]
+	if name == "" {
+		return "", validator.NewFieldError("company_name", "required", "is required")
+	}
+
+	return name, nil
+}
+
+func idParam(r *http.Request) (uuid.UUID, error) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		return uuid.Nil, paramError{fmt.Errorf("invalid id: %w", err)}
+	}
+
+	return id, nil
+}
+
+func companyTypeFromString(compType string) (domain.CompanyType, error) {
+	companyType, err := domain.GetCompTypeFromString(compType)
+	if err != nil {
+		return 0, validator.NewFieldError("type", "oneof", "must be one of [Corporations NonProfit Cooperative Sole Proprietorship]")
+	}
+
+	return companyType, nil
+}
+
+// createToCompany validates a Create body into the company to create.
+func (c *Company) createToCompany(reqData Create) (domain.Company, error) {
+	if err := c.validator.Struct(reqData); err != nil {
+		return domain.Company{}, err
+	}
+	if err := checkName(reqData.Name); err != nil {
+		return domain.Company{}, err
+	}
+
+	companyType, err := companyTypeFromString(reqData.Type)
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return domain.Company{
+		Name:            reqData.Name,
+		Description:     &reqData.Description,
+		EmployeesNumber: reqData.EmployeesNumber,
+		IsRegistered:    reqData.IsRegistered,
+		Type:            &companyType,
+	}, nil
+}
+
+// decodePatch reads and validates a Patch body into the fields to update.
+func (c *Company) decodePatch(r *http.Request) (domain.Company, error) {
 	reqData := Patch{}
 	err := json.NewDecoder(r.Body).Decode(&reqData)
 	if err != nil {
-		c.logger.Named(fmt.Sprintf("%s:%s", errorSection, patch)).Debug(err.Error())
-		w.WriteHeader(http.StatusBadRequest)
-		return
+		return domain.Company{}, decodeError{err}
 	}
 
+	return c.patchToCompany(reqData)
+}
+
+// patchToCompany validates a Patch body into the fields to update.
+func (c *Company) patchToCompany(reqData Patch) (domain.Company, error) {
 	if err := c.validator.Struct(reqData); err != nil {
-		c.logger.Named(fmt.Sprintf("%s:%s", errorSection, patch)).Debug(err.Error())
-		w.WriteHeader(http.StatusNotAcceptable)
-		return
+		return domain.Company{}, err
+	}
+	if err := checkName(reqData.Name); err != nil {
+		return domain.Company{}, err
 	}
 
 	companyPatch := domain.Company{
@@ -250,11 +674,9 @@ func (c *Company) patch(w http.ResponseWriter, r *http.Request) {
 		IsRegistered:    reqData.IsRegistered,
 	}
 	if reqData.Type != nil {
-		companyType, err := domain.GetCompTypeFromString(*reqData.Type)
+		companyType, err := companyTypeFromString(*reqData.Type)
 		if err != nil {
-			c.logger.Named(fmt.Sprintf("%s:%s", errorSection, patch)).Debug(err.Error())
-			w.WriteHeader(http.StatusNotAcceptable)
-			return
+			return domain.Company{}, err
 		}
 
 		companyPatch.Type = &companyType
@@ -262,22 +684,25 @@ func (c *Company) patch(w http.ResponseWriter, r *http.Request) {
 		companyPatch.Type = nil
 	}
 
-	err = c.companyService.Patch(companyPatch, nameParam)
-	if err != nil {
-		c.logger.Named(fmt.Sprintf("%s:%s", errorSection, patch)).Debug(err.Error())
+	return companyPatch, nil
+}
 
-		if errors.Is(err, postres.NoRowsErr) {
-			w.WriteHeader(http.StatusConflict)
-			resp, _ := json.Marshal(Error{
-				Message: "no entries affected",
-			})
-			w.Write(resp)
-			return
-		}
+// writeCompany answers with the company and its ETag, or 304 when If-None-Match already matches it.
+func (c *Company) writeCompany(w http.ResponseWriter, r *http.Request, method string, company domain.Company) {
+	tag := etag(company)
+	w.Header().Set("ETag", tag)
+
+	if header := r.Header.Get("If-None-Match"); header != "" && etagMatches(header, tag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 
-		w.WriteHeader(http.StatusBadRequest)
+	response, err := json.Marshal(getConverter(company))
+	if err != nil {
+		c.writeError(w, r, method, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
+	w.Write(response)
 }
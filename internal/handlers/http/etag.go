@@ -0,0 +1,63 @@
+package http
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/pkg/postres"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// etag identifies a company revision. The id is part of the tag so a company recreated
+// under the same name never matches a tag of its predecessor.
+func etag(company domain.Company) string {
+	return fmt.Sprintf(`"%s.%d"`, company.ID, company.Version)
+}
+
+// etagMatches reports whether tag is listed in an If-Match/If-None-Match header value.
+// If-Match requires the strong comparison, If-None-Match the weak one (RFC 9110 13.1).
+func etagMatches(header, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatch resolves the If-Match header against the current company. It returns a nil
+// Precondition when the header is absent and postres.PreconditionFailed when no tag matches.
+func ifMatch(r *http.Request, current func() (domain.Company, error)) (*domain.Precondition, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil, nil
+	}
+
+	company, err := current()
+	if err != nil {
+		if errors.Is(err, postres.NoRowsErr) {
+			return nil, postres.PreconditionFailed
+		}
+		return nil, err
+	}
+
+	if !etagMatches(header, etag(company), false) {
+		return nil, postres.PreconditionFailed
+	}
+
+	return &domain.Precondition{
+		ID:      company.ID,
+		Version: company.Version,
+	}, nil
+}
@@ -0,0 +1,323 @@
+package http
+
+import (
+	"bufio"
+	"company-crud/internal/domain"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	exportCSV    = "csv"
+	exportNDJSON = "ndjson"
+	exportJSON   = "json"
+)
+
+// @Summary      Export companies
+// @Description  Streams every company matching the filters, read from a single snapshot of the table, as CSV, NDJSON or a JSON array. The response is gzipped when the client accepts it. The columns are those of the companies listing, deleted_at only when the deleted companies are included, unless picked through columns.
+// @Tags         company
+// @Produce      json
+// @Produce      application/x-ndjson
+// @Produce      text/csv
+// @Security BearerAuth
+// @Param        format			query	string	false	"csv, ndjson or json (default)"
+// @Param        columns		query	string	false	"comma separated columns, e.g. id,name,type"
+// @Param        type			query	string	false	"company type"
+// @Param        registered		query	bool	false	"registered"
+// @Param        include_deleted	query	bool	false	"export the deleted companies too, needs companies:admin"
+// @Param        min_employees	query	int		false	"minimum amount of employees"
+// @Param        max_employees	query	int		false	"maximum amount of employees"
+// @Param        created_after	query	string	false	"RFC 3339 timestamp, inclusive"
+// @Param        created_before	query	string	false	"RFC 3339 timestamp, exclusive"
+// @Param        updated_after	query	string	false	"RFC 3339 timestamp, inclusive"
+// @Param        updated_before	query	string	false	"RFC 3339 timestamp, exclusive"
+// @Param        sort			query	string	false	"name, created_at or updated_at"
+// @Param        order			query	string	false	"asc or desc"
+// @Param        Accept-Encoding	header	string	false	"gzip compresses the export"
+// @Success      200	{array}  Get
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Router       /companies/export [get]
+func (c *Company) export(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params, err := exportParamsFromQuery(query)
+	if err != nil {
+		c.writeError(w, r, exportM, paramError{err})
+		return
+	}
+	if params.Filter.IncludeDeleted {
+		if err := requireScope(r.Context(), domain.ScopeCompaniesAdmin); err != nil {
+			c.writeError(w, r, exportM, err)
+			return
+		}
+	}
+
+	columns, err := exportColumns(query.Get("columns"), params.Filter.IncludeDeleted)
+	if err != nil {
+		c.writeError(w, r, exportM, paramError{err})
+		return
+	}
+
+	format := query.Get("format")
+	var contentType string
+	switch format {
+	case exportCSV:
+		contentType = csvContentType
+	case exportNDJSON:
+		contentType = ndjsonContentType
+	case "", exportJSON:
+		format = exportJSON
+		contentType = "application/json"
+	default:
+		c.writeError(w, r, exportM, paramError{fmt.Errorf("format must be %s, %s or %s", exportCSV, exportNDJSON, exportJSON)})
+		return
+	}
+
+	// Nothing is written before the first company is read, so that failing to start the
+	// export is still answered with a problem.
+	var out exportWriter
+	var gzipWriter *gzip.Writer
+	start := func() error {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="companies.%s"`, format))
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		var body io.Writer = w
+		if acceptsGzip(r) {
+			w.Header().Set("Content-Encoding", "gzip")
+			gzipWriter = gzip.NewWriter(w)
+			body = gzipWriter
+		}
+		w.WriteHeader(http.StatusOK)
+
+		out = newExportWriter(format, body, columns)
+		return out.begin()
+	}
+
+	err = c.companyService.Export(r.Context(), params, func(company domain.Company) error {
+		if out == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		return out.write(exportValues(getConverter(company), columns))
+	})
+	if err == nil && out == nil {
+		err = start()
+	}
+	if err == nil {
+		err = out.end()
+	}
+	if err == nil && gzipWriter != nil {
+		err = gzipWriter.Close()
+	}
+
+	if err != nil {
+		if out == nil {
+			c.writeError(w, r, exportM, err)
+			return
+		}
+
+		// The status is long sent, breaking the connection is the only way left to tell
+		// the client that the export is incomplete.
+		c.logger.For(r.Context()).Named(fmt.Sprintf("%s:%s", errorSection, exportM)).Error(err.Error())
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// acceptsGzip tells whether the Accept-Encoding header of r lists gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// exportColumns parses the columns parameter, which defaults to every column but
+// deleted_at, included along with the deleted companies.
+func exportColumns(param string, includeDeleted bool) ([]string, error) {
+	if param == "" {
+		columns := []string{"id", "name", "description", "amount_of_employees", "registered", "type", "created_at", "updated_at", "version"}
+		if includeDeleted {
+			columns = append(columns, "deleted_at")
+		}
+		return columns, nil
+	}
+
+	columns := strings.Split(param, ",")
+	seen := make(map[string]bool, len(columns))
+	for i, column := range columns {
+		column = strings.TrimSpace(column)
+		switch column {
+		case "id", "name", "description", "amount_of_employees", "registered", "type", "created_at", "updated_at", "version", "deleted_at":
+		default:
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("duplicate column %q", column)
+		}
+		seen[column] = true
+		columns[i] = column
+	}
+
+	return columns, nil
+}
+
+// exportValues returns the values of the columns of company, in order.
+func exportValues(company Get, columns []string) []interface{} {
+	values := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		switch column {
+		case "id":
+			values = append(values, company.ID)
+		case "name":
+			values = append(values, company.Name)
+		case "description":
+			values = append(values, company.Description)
+		case "amount_of_employees":
+			values = append(values, company.EmployeesNumber)
+		case "registered":
+			values = append(values, company.IsRegistered)
+		case "type":
+			values = append(values, company.Type)
+		case "created_at":
+			values = append(values, company.CreatedAt)
+		case "updated_at":
+			values = append(values, company.UpdatedAt)
+		case "version":
+			values = append(values, company.Version)
+		case "deleted_at":
+			values = append(values, company.DeletedAt)
+		}
+	}
+
+	return values
+}
+
+// exportWriter writes the companies of an export in its format.
+type exportWriter interface {
+	begin() error
+	write(values []interface{}) error
+	// end completes the export and flushes what is still buffered.
+	end() error
+}
+
+func newExportWriter(format string, w io.Writer, columns []string) exportWriter {
+	if format == exportCSV {
+		return &csvExport{writer: csv.NewWriter(w), columns: columns}
+	}
+
+	return &jsonExport{writer: bufio.NewWriter(w), columns: columns, array: format == exportJSON}
+}
+
+// csvExport writes a header row naming the columns, then a row per company.
+type csvExport struct {
+	writer  *csv.Writer
+	columns []string
+}
+
+func (e *csvExport) begin() error {
+	return e.writer.Write(e.columns)
+}
+
+func (e *csvExport) write(values []interface{}) error {
+	record := make([]string, 0, len(values))
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			record = append(record, v)
+		case int:
+			record = append(record, strconv.Itoa(v))
+		case bool:
+			record = append(record, strconv.FormatBool(v))
+		case time.Time:
+			record = append(record, v.Format(time.RFC3339Nano))
+		case *time.Time:
+			if v == nil {
+				record = append(record, "")
+			} else {
+				record = append(record, v.Format(time.RFC3339Nano))
+			}
+		default:
+			record = append(record, fmt.Sprint(v))
+		}
+	}
+
+	return e.writer.Write(record)
+}
+
+func (e *csvExport) end() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// jsonExport writes a JSON object per company, keyed by the columns in order, on its own
+// line for NDJSON or as the items of an array.
+type jsonExport struct {
+	writer   *bufio.Writer
+	columns  []string
+	array    bool
+	exported int
+}
+
+func (e *jsonExport) begin() error {
+	if e.array {
+		_, err := e.writer.WriteString("[")
+		return err
+	}
+
+	return nil
+}
+
+func (e *jsonExport) write(values []interface{}) error {
+	if e.array && e.exported > 0 {
+		e.writer.WriteString(",")
+	}
+	e.exported++
+
+	e.writer.WriteString("{")
+	for i, value := range values {
+		if i > 0 {
+			e.writer.WriteString(",")
+		}
+
+		key, _ := json.Marshal(e.columns[i])
+		e.writer.Write(key)
+		e.writer.WriteString(":")
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		e.writer.Write(encoded)
+	}
+	_, err := e.writer.WriteString("}")
+	if !e.array {
+		_, err = e.writer.WriteString("\n")
+	}
+
+	return err
+}
+
+func (e *jsonExport) end() error {
+	if e.array {
+		e.writer.WriteString("]")
+	}
+
+	return e.writer.Flush()
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"bytes"
+	"company-crud/internal/domain"
+	"company-crud/pkg/logger"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"time"
+)
+
+const idempotencyErrorSection = "idempotencyMiddleware"
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// recorder passes the response through while keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+
+	return rec.ResponseWriter.Write(b)
+}
+
+// idempotent persists the first response given to a request carrying an Idempotency-Key
+// and replays it to retries with the same key and body, until ttl expires. Keys are scoped
+// to the subject of the token, so callers never get each other's responses, and rejected
+// for tokens without one. Server errors,
+// and requests that got no response, aren't persisted so that they can be retried.
+func idempotent(store domain.IdempotencyDB, ttl time.Duration, log *logger.Logger) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			if key == "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			logger := log.For(r.Context()).Named(idempotencyErrorSection)
+
+			if len(key) > maxIdempotencyKeyLength {
+				writeProblem(w, r, Problem{
+					Type:   problemInvalidParameter,
+					Title:  "Invalid parameter",
+					Status: http.StatusBadRequest,
+					Detail: fmt.Sprintf("%s must be at most %d characters long", idempotencyKeyHeader, maxIdempotencyKeyLength),
+				})
+				return
+			}
+
+			// Without a subject, callers couldn't be told apart and would share their keys.
+			if principal, _ := domain.PrincipalFrom(r.Context()); principal.Subject == "" {
+				writeProblem(w, r, Problem{
+					Type:   problemInvalidParameter,
+					Title:  "Invalid parameter",
+					Status: http.StatusBadRequest,
+					Detail: fmt.Sprintf("%s needs a token with a sub claim", idempotencyKeyHeader),
+				})
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				logger.Debug(err.Error())
+				writeProblem(w, r, problemFromError(decodeError{err}))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.New()
+			hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+			hash.Write(body)
+			requestHash := hex.EncodeToString(hash.Sum(nil))
+
+			key = scopedKey(r.Context(), key)
+
+			record, reserved, err := store.Reserve(r.Context(), key, requestHash, ttl)
+			if err != nil {
+				logger.Error(err.Error())
+				writeProblem(w, r, problemFromError(err))
+				return
+			}
+
+			if !reserved {
+				replay(w, r, record, requestHash)
+				return
+			}
+
+			// The outcome is recorded even if the client went away meanwhile.
+			ctx := context.WithoutCancel(r.Context())
+
+			// Unless completed, the key is released, the handler panicking included, so that
+			// retries aren't turned away as in progress until the key expires.
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				if err := store.Release(ctx, key); err != nil {
+					logger.Error(err.Error())
+				}
+			}()
+
+			rec := &recorder{ResponseWriter: w}
+			h.ServeHTTP(rec, r)
+
+			if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+				return
+			}
+
+			headers := map[string]string{}
+			for _, name := range []string{"Content-Type", "Location", "ETag"} {
+				if value := rec.Header().Get(name); value != "" {
+					headers[name] = value
+				}
+			}
+
+			if err := store.Complete(ctx, key, rec.status, headers, rec.body.Bytes()); err != nil {
+				logger.Error(err.Error())
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// scopedKey is the key stored for the Idempotency-Key key sent on behalf of the principal of
+// ctx. Hashing keeps it within the key column, whatever the length of the subject.
+func scopedKey(ctx context.Context, key string) string {
+	principal, _ := domain.PrincipalFrom(ctx)
+
+	hash := sha256.New()
+	hash.Write([]byte(principal.Subject))
+	hash.Write([]byte{0})
+	hash.Write([]byte(key))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, r *http.Request, record domain.IdempotencyRecord, requestHash string) {
+	if record.RequestHash != requestHash {
+		writeProblem(w, r, Problem{
+			Type:   problemIdempotencyReuse,
+			Title:  "Idempotency-Key reused",
+			Status: http.StatusUnprocessableEntity,
+			Detail: "the key was already used for a different request",
+		})
+		return
+	}
+
+	if record.StatusCode == 0 {
+		writeProblem(w, r, Problem{
+			Type:   problemIdempotencyBusy,
+			Title:  "Request in progress",
+			Status: http.StatusConflict,
+			Detail: "a request with the same key is still being processed",
+		})
+		return
+	}
+
+	for name, value := range record.Headers {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set(idempotentReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
@@ -0,0 +1,421 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"company-crud/internal/domain"
+	"company-crud/pkg/postres"
+	"company-crud/pkg/validator"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	csvContentType    = "text/csv"
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// importChunkSize is the number of rows applied per transaction. It bounds the memory an
+// import takes, whatever the size of the file.
+const importChunkSize = 500
+
+// maxImportLine is the longest NDJSON line accepted.
+const maxImportLine = 1 << 20
+
+// maxImportErrors is the number of failed rows listed in the JSON report. All of them are
+// in the CSV one, which is spooled to a temporary file.
+const maxImportErrors = 100
+
+// @Summary      Import companies
+// @Description  Creates the companies of a CSV file, whose header row names the columns after the Create fields, or of an NDJSON file holding a Create object per line. The file is streamed and applied 500 rows at a time, each row succeeding or failing on its own. With upsert, the companies whose name exists are updated instead. A dry run only reports what would happen. The first 100 rows that failed are listed in the report, all of them in the CSV one, returned when it is the accepted type.
+// @Tags         company
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Produce      text/csv
+// @Security BearerAuth
+// @Param        file	body	string  true  "CSV or NDJSON companies"
+// @Param        dry_run	query	bool	false	"validate and report without saving"
+// @Param        upsert	query	bool	false	"update the companies whose name exists"
+// @Success      200	{object}  ImportReport
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      415	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Router       /companies/import [post]
+func (c *Company) importCompanies(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	dryRun, err := boolParam(query, "dry_run")
+	if err != nil {
+		c.writeError(w, r, importM, paramError{err})
+		return
+	}
+	upsert, err := boolParam(query, "upsert")
+	if err != nil {
+		c.writeError(w, r, importM, paramError{err})
+		return
+	}
+
+	var rows importRows
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case csvContentType:
+		rows, err = newCSVRows(r.Body)
+	case ndjsonContentType:
+		rows = newNDJSONRows(r.Body)
+	default:
+		err = mediaTypeError{mediaType}
+	}
+	if err != nil {
+		c.writeError(w, r, importM, err)
+		return
+	}
+
+	op := domain.BatchCreate
+	if upsert {
+		op = domain.BatchUpsert
+	}
+
+	report := ImportReport{DryRun: dryRun, Errors: []ImportError{}}
+	failures := importFailures{report: &report}
+
+	if accepts(r, csvContentType) {
+		file, err := os.CreateTemp("", "import-errors-*.csv")
+		if err != nil {
+			c.writeError(w, r, importM, err)
+			return
+		}
+		defer os.Remove(file.Name())
+		defer file.Close()
+
+		failures.file = file
+		failures.csv = csv.NewWriter(file)
+		failures.csv.Write(importErrorColumns)
+	}
+
+	// A dry run rolls every chunk back, so the names the earlier chunks would have saved
+	// are kept to tell the duplicates of the later ones.
+	saved := map[string]struct{}{}
+	pending := make([]importRow, 0, importChunkSize)
+	items := make([]domain.BatchItem, 0, importChunkSize)
+	apply := func() error {
+		// The rows failing to save are only known once their chunk is applied, the failures
+		// are held until then to be reported in line order.
+		defer failures.flush()
+		if len(items) == 0 {
+			return nil
+		}
+
+		results, err := c.companyService.Batch(r.Context(), domain.Batch{
+			Items:        items,
+			SummaryEvent: c.cfg.BatchSummaryEvent,
+			DryRun:       dryRun,
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, result := range results {
+			_, savedBefore := saved[items[i].Name]
+			savedBefore = savedBefore && dryRun
+
+			switch {
+			case result.Err != nil:
+				failures.add(pending[i], result.Err)
+			case savedBefore && op == domain.BatchCreate:
+				failures.add(pending[i], postres.DuplicateKey)
+			case result.Created && !savedBefore:
+				report.Created++
+			default:
+				report.Updated++
+			}
+
+			if dryRun && result.Err == nil {
+				saved[items[i].Name] = struct{}{}
+			}
+		}
+
+		pending = pending[:0]
+		items = items[:0]
+		return nil
+	}
+
+	for {
+		row, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			c.writeError(w, r, importM, decodeError{err})
+			return
+		}
+		report.Rows++
+
+		item := domain.BatchItem{Op: op, Name: row.data.Name}
+		if row.err == nil {
+			item.Company, row.err = c.createToCompany(row.data)
+		}
+		if row.err != nil {
+			failures.add(row, row.err)
+		} else {
+			pending = append(pending, row)
+			items = append(items, item)
+		}
+
+		if len(items) == importChunkSize || len(failures.pending) == importChunkSize {
+			if err := apply(); err != nil {
+				c.writeError(w, r, importM, err)
+				return
+			}
+		}
+	}
+	if err := apply(); err != nil {
+		c.writeError(w, r, importM, err)
+		return
+	}
+
+	if failures.csv != nil {
+		if err := failures.rewind(); err != nil {
+			c.writeError(w, r, importM, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", csvContentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="import-errors.csv"`)
+		w.WriteHeader(http.StatusOK)
+		io.Copy(w, failures.file)
+		return
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		c.writeError(w, r, importM, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// accepts tells whether the Accept header of r lists mediaType.
+func accepts(r *http.Request, mediaType string) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		if t, _, err := mime.ParseMediaType(strings.TrimSpace(accepted)); err == nil && t == mediaType {
+			return true
+		}
+	}
+
+	return false
+}
+
+var importErrorColumns = []string{"line", "name", "status", "type", "detail"}
+
+// importFailures collects the rows of an import that failed. They are added as they come
+// and flushed in line order into the report, up to maxImportErrors, and into csv when set.
+type importFailures struct {
+	report  *ImportReport
+	pending []ImportError
+	file    *os.File
+	csv     *csv.Writer
+}
+
+func (f *importFailures) add(row importRow, err error) {
+	f.report.Failed++
+	f.pending = append(f.pending, ImportError{
+		Line:  row.line,
+		Name:  row.data.Name,
+		Error: problemFromError(err),
+	})
+}
+
+func (f *importFailures) flush() {
+	sort.SliceStable(f.pending, func(i, j int) bool {
+		return f.pending[i].Line < f.pending[j].Line
+	})
+
+	for _, importErr := range f.pending {
+		if len(f.report.Errors) < maxImportErrors {
+			f.report.Errors = append(f.report.Errors, importErr)
+		} else {
+			f.report.Truncated = true
+		}
+
+		if f.csv != nil {
+			f.csv.Write(importErrorRecord(importErr))
+		}
+	}
+	f.pending = f.pending[:0]
+}
+
+// rewind flushes csv and seeks file back to its start, to be read.
+func (f *importFailures) rewind() error {
+	f.csv.Flush()
+	if err := f.csv.Error(); err != nil {
+		return err
+	}
+
+	_, err := f.file.Seek(0, io.SeekStart)
+	return err
+}
+
+// importErrorRecord is the CSV line of a failed row.
+func importErrorRecord(importErr ImportError) []string {
+	detail := importErr.Error.Detail
+	for _, violation := range importErr.Error.Violations {
+		detail += fmt.Sprintf("; %s: %s", violation.Field, violation.Message)
+	}
+
+	return []string{
+		strconv.Itoa(importErr.Line),
+		importErr.Name,
+		strconv.Itoa(importErr.Error.Status),
+		importErr.Error.Type,
+		strings.TrimPrefix(detail, "; "),
+	}
+}
+
+// importRow is a row of an imported file, or why it couldn't be read.
+type importRow struct {
+	line int
+	data Create
+	err  error
+}
+
+// importRows reads an imported file a row at a time. next returns io.EOF after the last
+// row, and other errors when the file can't be read any further.
+type importRows interface {
+	next() (importRow, error)
+}
+
+type csvRows struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+// newCSVRows reads the header row, whose columns are named after the Create fields.
+func newCSVRows(body io.Reader) (*csvRows, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, decodeError{errors.New("missing header row")}
+	}
+	if err != nil {
+		return nil, decodeError{err}
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		// Spreadsheets tend to start their exports with a byte order mark.
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		switch column {
+		case "name", "description", "amount_of_employees", "registered", "type":
+		default:
+			return nil, decodeError{fmt.Errorf("unknown column %q", column)}
+		}
+		if _, ok := columns[column]; ok {
+			return nil, decodeError{fmt.Errorf("duplicate column %q", column)}
+		}
+		columns[column] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, decodeError{errors.New("missing name column")}
+	}
+
+	return &csvRows{reader: reader, columns: columns}, nil
+}
+
+func (rows *csvRows) next() (importRow, error) {
+	record, err := rows.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return importRow{line: parseErr.StartLine, err: decodeError{err}}, nil
+		}
+		return importRow{}, err
+	}
+
+	line, _ := rows.reader.FieldPos(0)
+	row := importRow{line: line}
+	value := func(column string) string {
+		if i, ok := rows.columns[column]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row.data.Name = value("name")
+	row.data.Description = value("description")
+	row.data.Type = value("type")
+
+	if v := value("amount_of_employees"); v != "" {
+		employees, err := strconv.Atoi(v)
+		if err != nil {
+			row.err = validator.NewFieldError("amount_of_employees", "number", "must be a whole number")
+			return row, nil
+		}
+		row.data.EmployeesNumber = &employees
+	}
+
+	if v := value("registered"); v != "" {
+		registered, err := strconv.ParseBool(v)
+		if err != nil {
+			row.err = validator.NewFieldError("registered", "boolean", "must be true or false")
+			return row, nil
+		}
+		row.data.IsRegistered = &registered
+	}
+
+	return row, nil
+}
+
+type ndjsonRows struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONRows(body io.Reader) *ndjsonRows {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+
+	return &ndjsonRows{scanner: scanner}
+}
+
+func (rows *ndjsonRows) next() (importRow, error) {
+	for rows.scanner.Scan() {
+		rows.line++
+		text := bytes.TrimSpace(rows.scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		row := importRow{line: rows.line}
+		if err := json.Unmarshal(text, &row.data); err != nil {
+			row.err = decodeError{err}
+		}
+
+		return row, nil
+	}
+
+	if err := rows.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return importRow{}, fmt.Errorf("line %d is longer than %d bytes", rows.line+1, maxImportLine)
+		}
+		return importRow{}, err
+	}
+
+	return importRow{}, io.EOF
+}
@@ -1,18 +1,24 @@
 package http
 
 import (
+	"company-crud/internal/domain"
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
 
 type Create struct {
-	Name            string `json:"name" validate:"required"`
-	Description     string `json:"description"`
+	Name            string `json:"name" validate:"required,max=15"`
+	Description     string `json:"description" validate:"max=3000"`
 	EmployeesNumber *int   `json:"amount_of_employees" validate:"required"`
-	IsRegistered    bool   `json:"registered" validate:"required"`
+	IsRegistered    *bool  `json:"registered" validate:"required"`
 	Type            string `json:"type" validate:"required"`
 }
 
+type Created struct {
+	ID uuid.UUID `json:"id"`
+}
+
 type Get struct {
 	ID              uuid.UUID `json:"id"`
 	Name            string    `json:"name"`
@@ -22,16 +28,153 @@ type Get struct {
 	Type            string    `json:"type"`
 	UpdatedAt       time.Time `json:"updated_at"`
 	CreatedAt       time.Time `json:"created_at"`
+	Version         int       `json:"version"`
+	// DeletedAt is only set on the deleted companies, returned to admins asking for them.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type SearchResult struct {
+	Company Get `json:"company"`
+	// Rank orders the results, the better matches first.
+	Rank float64 `json:"rank"`
+	// NameHighlight and Snippet, an excerpt of the description, are HTML escaped and wrap
+	// the matched words in <mark> tags.
+	NameHighlight string `json:"name_highlight"`
+	Snippet       string `json:"snippet"`
+}
+
+type SearchList struct {
+	Results    []SearchResult `json:"results"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type List struct {
+	Companies  []Get  `json:"companies"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type AuditEntry struct {
+	ID          int64           `json:"id"`
+	CompanyID   uuid.UUID       `json:"company_id"`
+	CompanyName string          `json:"company_name"`
+	Operation   string          `json:"operation"`
+	Actor       string          `json:"actor"`
+	RequestID   string          `json:"request_id"`
+	Before      json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After       json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type AuditList struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type Revision struct {
+	Version   int    `json:"version"`
+	Operation string `json:"operation"`
+	// Company is the state the change left the company in, made at its updated_at.
+	Company Get `json:"company"`
+}
+
+type RevisionList struct {
+	Revisions  []Revision `json:"revisions"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+const (
+	batchEventsItem    = "item"
+	batchEventsSummary = "summary"
+)
+
+type BatchRequest struct {
+	// Atomic batches are all or nothing.
+	Atomic bool `json:"atomic"`
+	// Events is item, for an event per change, or summary, for a single company.batch
+	// event. The server default applies when empty.
+	Events     string           `json:"events" validate:"omitempty,oneof=item summary"`
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,max=500,dive"`
+}
+
+type BatchOperation struct {
+	Op string `json:"op" validate:"required,oneof=create patch delete"`
+	// Name is the current name of the company to patch or delete.
+	Name string `json:"name,omitempty"`
+	// Data is a Create body for create, a Patch body for patch.
+	Data json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+}
+
+type BatchResponse struct {
+	// Results are in the order of the operations.
+	Results []BatchResult `json:"results"`
+}
+
+type BatchResult struct {
+	Status int        `json:"status"`
+	ID     *uuid.UUID `json:"id,omitempty"`
+	Error  *Problem   `json:"error,omitempty"`
+}
+
+// ImportReport sums up an import. Rows counts the rows read, Errors lists the first of
+// those that failed, and Truncated tells whether there were more.
+type ImportReport struct {
+	DryRun    bool          `json:"dry_run"`
+	Rows      int           `json:"rows"`
+	Created   int           `json:"created"`
+	Updated   int           `json:"updated"`
+	Failed    int           `json:"failed"`
+	Errors    []ImportError `json:"errors"`
+	Truncated bool          `json:"truncated"`
+}
+
+type ImportError struct {
+	// Line is the line of the row in the file.
+	Line  int     `json:"line"`
+	Name  string  `json:"name,omitempty"`
+	Error Problem `json:"error"`
 }
 
 type Patch struct {
-	Name            string  `json:"name"`
-	Description     *string `json:"description"`
+	Name            string  `json:"name" validate:"omitempty,max=15"`
+	Description     *string `json:"description" validate:"omitempty,max=3000"`
 	EmployeesNumber *int    `json:"amount_of_employees"`
 	IsRegistered    *bool   `json:"registered"`
 	Type            *string `json:"type"`
 }
 
-type Error struct {
-	Message string `json:"error_message"`
+func getConverter(company domain.Company) Get {
+	return Get{
+		ID:              company.ID,
+		Name:            company.Name,
+		Description:     *company.Description,
+		EmployeesNumber: *company.EmployeesNumber,
+		IsRegistered:    *company.IsRegistered,
+		Type:            company.Type.String(),
+		UpdatedAt:       company.UpdatedAt,
+		CreatedAt:       company.CreatedAt,
+		Version:         company.Version,
+		DeletedAt:       company.DeletedAt,
+	}
+}
+
+func auditConverter(entry domain.AuditEntry) AuditEntry {
+	return AuditEntry{
+		ID:          entry.ID,
+		CompanyID:   entry.CompanyID,
+		CompanyName: entry.CompanyName,
+		Operation:   string(entry.Operation),
+		Actor:       entry.Actor,
+		RequestID:   entry.RequestID,
+		Before:      entry.Before,
+		After:       entry.After,
+		CreatedAt:   entry.CreatedAt,
+	}
+}
+
+func revisionConverter(revision domain.Revision) Revision {
+	return Revision{
+		Version:   revision.Company.Version,
+		Operation: string(revision.Operation),
+		Company:   getConverter(revision.Company),
+	}
 }
@@ -1,45 +1,216 @@
 package http
 
 import (
+	"company-crud/internal/domain"
+	"company-crud/pkg/http_server"
+	"company-crud/pkg/jwtkeys"
+	"context"
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/mux"
 	"net/http"
+	"strings"
+	"time"
 )
 
-func validateToken(signature string) mux.MiddlewareFunc {
+// withTimeout bounds the context of every request by d, zero disables it. Work running
+// past it is abandoned and answered with 504.
+func withTimeout(d time.Duration) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		if d <= 0 {
+			return h
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// tokenLeeway absorbs the clock skew with the token issuer when checking exp and nbf.
+const tokenLeeway = 30 * time.Second
+
+const (
+	authRealm         = "company-crud"
+	legacyTokenHeader = "Token"
+)
+
+// Bearer token error codes, see RFC 6750 section 3.1.
+const (
+	errInvalidRequest    = "invalid_request"
+	errInvalidToken      = "invalid_token"
+	errInsufficientScope = "insufficient_scope"
+)
+
+// tokenClaims are the claims the API understands. Scopes are granted through either the
+// space separated scope claim or the roles claim.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+func (tc tokenClaims) scopes() []string {
+	return append(strings.Fields(tc.Scope), tc.Roles...)
+}
+
+// authenticate verifies the signature of the request token and its exp, nbf, and when
+// configured iss and aud claims, answering 401 when any is wrong. The principal the token
+// was issued to is put in the request context.
+func authenticate(cfg Config) mux.MiddlewareFunc {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtkeys.Algorithms()),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(tokenLeeway),
+	}
+	if cfg.TokenIssuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.TokenIssuer))
+	}
+	if cfg.TokenAudience != "" {
+		options = append(options, jwt.WithAudience(cfg.TokenAudience))
+	}
+	parser := jwt.NewParser(options...)
+
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			headerToken := r.Header.Get("Token")
+			headerToken, ok := bearerToken(r, cfg.LegacyTokenHeader)
+			if !ok {
+				writeUnauthorized(w, r, errInvalidRequest, "malformed Authorization header")
+				return
+			}
 			if headerToken == "" {
-				w.WriteHeader(http.StatusForbidden)
+				writeUnauthorized(w, r, "", "missing token")
 				return
 			}
 
-			token, err := jwt.Parse(headerToken, func(t *jwt.Token) (interface{}, error) {
-				_, ok := t.Method.(*jwt.SigningMethodHMAC)
-				if !ok {
-					return false, errors.New("invalid Token")
-				}
-				return []byte(signature), nil
-			})
-
+			claims := tokenClaims{}
+			_, err := parser.ParseWithClaims(headerToken, &claims, cfg.TokenKeys)
 			if err != nil {
-				w.WriteHeader(http.StatusForbidden)
+				writeUnauthorized(w, r, errInvalidToken, tokenErrorDetail(err))
 				return
 			}
 
-			_, ok := token.Claims.(jwt.MapClaims)
+			principal := domain.Principal{
+				Subject: claims.Subject,
+				Scopes:  claims.scopes(),
+			}
+			http_server.SetSubject(r.Context(), principal.Subject)
+
+			h.ServeHTTP(w, r.WithContext(domain.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// bearerToken returns the token of the Authorization header, or of the legacy Token header
+// when allowed, and "" when there is none. It fails when the Authorization header isn't
+// of the Bearer scheme.
+func bearerToken(r *http.Request, legacy bool) (string, bool) {
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		scheme, token, found := strings.Cut(authorization, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return "", false
+		}
+
+		token = strings.TrimSpace(token)
+		return token, token != ""
+	}
+
+	if legacy {
+		return r.Header.Get(legacyTokenHeader), true
+	}
+
+	return "", true
+}
+
+// tokenErrorDetail tells the client why its token was rejected, without echoing it.
+func tokenErrorDetail(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "token expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return "token not valid yet"
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return "token without expiration"
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return "invalid token issuer"
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return "invalid token audience"
+	default:
+		return "invalid token"
+	}
+}
+
+// authorize answers 403 to the authenticated requests whose principal wasn't granted scope.
+func authorize(scope string) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := domain.PrincipalFrom(r.Context())
 			if !ok {
-				w.WriteHeader(http.StatusForbidden)
+				writeUnauthorized(w, r, "", "missing token")
 				return
 			}
 
-			if !token.Valid {
-				w.WriteHeader(http.StatusForbidden)
+			if !principal.HasScope(scope) {
+				writeForbidden(w, r, scope)
 				return
 			}
+
 			h.ServeHTTP(w, r)
 		})
 	}
 }
+
+// requireScope fails with a scopeError unless the principal was granted scope, for the
+// parts of a request that need more than its route does.
+func requireScope(ctx context.Context, scope string) error {
+	principal, _ := domain.PrincipalFrom(ctx)
+	if !principal.HasScope(scope) {
+		return scopeError{scope}
+	}
+
+	return nil
+}
+
+// challenge builds the WWW-Authenticate header value telling the client how to
+// authenticate, and what was wrong with its token if errorCode is set.
+func challenge(errorCode, description, scope string) string {
+	value := fmt.Sprintf("Bearer realm=%q", authRealm)
+	if errorCode != "" {
+		value += fmt.Sprintf(", error=%q", errorCode)
+	}
+	if description != "" {
+		value += fmt.Sprintf(", error_description=%q", description)
+	}
+	if scope != "" {
+		value += fmt.Sprintf(", scope=%q", scope)
+	}
+
+	return value
+}
+
+// writeForbidden answers 403 with a challenge naming the scope the token lacks.
+func writeForbidden(w http.ResponseWriter, r *http.Request, scope string) {
+	w.Header().Set("WWW-Authenticate", challenge(errInsufficientScope, "", scope))
+	writeProblem(w, r, problemFromError(scopeError{scope}))
+}
+
+// writeUnauthorized answers 401 with a Bearer challenge. errorCode is left empty when the
+// request had no credentials at all.
+func writeUnauthorized(w http.ResponseWriter, r *http.Request, errorCode, detail string) {
+	description := ""
+	if errorCode != "" {
+		description = detail
+	}
+	w.Header().Set("WWW-Authenticate", challenge(errorCode, description, ""))
+
+	writeProblem(w, r, Problem{
+		Type:   problemUnauthorized,
+		Title:  "Unauthorized",
+		Status: http.StatusUnauthorized,
+		Detail: detail,
+	})
+}
@@ -0,0 +1,216 @@
+package http
+
+import (
+	"company-crud/pkg/postres"
+	"company-crud/pkg/validator"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// Problem types are stable identifiers clients can switch on, see RFC 7807.
+const (
+	problemNotFound         = "/problems/not-found"
+	problemDuplicate        = "/problems/duplicate-name"
+	problemPrecondition     = "/problems/precondition-failed"
+	problemValidation       = "/problems/validation"
+	problemMalformedBody    = "/problems/malformed-body"
+	problemInvalidParameter = "/problems/invalid-parameter"
+	problemUnauthorized     = "/problems/unauthorized"
+	problemForbidden        = "/problems/forbidden"
+	problemNotApplied       = "/problems/not-applied"
+	problemMediaType        = "/problems/unsupported-media-type"
+	problemIdempotencyReuse = "/problems/idempotency-key-reused"
+	problemIdempotencyBusy  = "/problems/idempotency-key-in-progress"
+	problemTimeout          = "/problems/timeout"
+	problemUnavailable      = "/problems/unavailable"
+	problemInternal         = "/problems/internal"
+)
+
+const problemContentType = "application/problem+json"
+
+type Problem struct {
+	Type       string                     `json:"type"`
+	Title      string                     `json:"title"`
+	Status     int                        `json:"status"`
+	Detail     string                     `json:"detail,omitempty"`
+	Instance   string                     `json:"instance,omitempty"`
+	Violations []validator.FieldViolation `json:"violations,omitempty"`
+}
+
+// decodeError marks a request body that couldn't be decoded.
+type decodeError struct {
+	error
+}
+
+// paramError marks a malformed path or query parameter.
+type paramError struct {
+	error
+}
+
+// mediaTypeError marks a request body of a type the endpoint doesn't read.
+type mediaTypeError struct {
+	mediaType string
+}
+
+func (e mediaTypeError) Error() string {
+	return fmt.Sprintf("unsupported content type %q", e.mediaType)
+}
+
+// scopeError marks a request needing a scope its token wasn't granted.
+type scopeError struct {
+	scope string
+}
+
+func (e scopeError) Error() string {
+	return fmt.Sprintf("the token lacks the %s scope", e.scope)
+}
+
+// problemFromError maps the errors surfacing from the lower layers to the problem
+// answered to the client. Anything unknown is reported as an internal error.
+func problemFromError(err error) Problem {
+	if violations := validator.Violations(err); violations != nil {
+		return Problem{
+			Type:       problemValidation,
+			Title:      "Validation failed",
+			Status:     http.StatusUnprocessableEntity,
+			Detail:     "one or more fields are invalid",
+			Violations: violations,
+		}
+	}
+
+	var decodeErr decodeError
+	var paramErr paramError
+	var scopeErr scopeError
+	var mediaTypeErr mediaTypeError
+	switch {
+	case errors.Is(err, postres.QueryTimeout):
+		return Problem{
+			Type:   problemUnavailable,
+			Title:  "Service unavailable",
+			Status: http.StatusServiceUnavailable,
+			Detail: "the database did not answer in time",
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		return Problem{
+			Type:   problemTimeout,
+			Title:  "Request timed out",
+			Status: http.StatusGatewayTimeout,
+		}
+	case errors.Is(err, postres.NoRowsErr):
+		return Problem{
+			Type:   problemNotFound,
+			Title:  "Company not found",
+			Status: http.StatusNotFound,
+		}
+	case errors.Is(err, postres.DuplicateKey):
+		return Problem{
+			Type:   problemDuplicate,
+			Title:  "Company name already in use",
+			Status: http.StatusConflict,
+		}
+	case errors.Is(err, postres.PreconditionFailed):
+		return Problem{
+			Type:   problemPrecondition,
+			Title:  "Precondition failed",
+			Status: http.StatusPreconditionFailed,
+			Detail: "the company was modified since the given ETag",
+		}
+	case errors.Is(err, postres.NotApplied):
+		return Problem{
+			Type:   problemNotApplied,
+			Title:  "Not applied",
+			Status: http.StatusFailedDependency,
+			Detail: "another operation of the atomic batch failed",
+		}
+	case errors.Is(err, postres.InvalidArgumentsForBuildingquery):
+		return Problem{
+			Type:   problemValidation,
+			Title:  "Validation failed",
+			Status: http.StatusUnprocessableEntity,
+			Detail: "at least one field has to be set",
+		}
+	case errors.Is(err, postres.InvalidCursor):
+		return Problem{
+			Type:   problemInvalidParameter,
+			Title:  "Invalid parameter",
+			Status: http.StatusBadRequest,
+			Detail: "invalid cursor",
+		}
+	case errors.As(err, &scopeErr):
+		return Problem{
+			Type:   problemForbidden,
+			Title:  "Forbidden",
+			Status: http.StatusForbidden,
+			Detail: scopeErr.Error(),
+		}
+	case errors.As(err, &decodeErr):
+		return Problem{
+			Type:   problemMalformedBody,
+			Title:  "Malformed request body",
+			Status: http.StatusBadRequest,
+			Detail: decodeErr.Error(),
+		}
+	case errors.As(err, &mediaTypeErr):
+		return Problem{
+			Type:   problemMediaType,
+			Title:  "Unsupported media type",
+			Status: http.StatusUnsupportedMediaType,
+			Detail: mediaTypeErr.Error(),
+		}
+	case errors.As(err, &paramErr):
+		return Problem{
+			Type:   problemInvalidParameter,
+			Title:  "Invalid parameter",
+			Status: http.StatusBadRequest,
+			Detail: paramErr.Error(),
+		}
+	default:
+		return Problem{
+			Type:   problemInternal,
+			Title:  "Internal server error",
+			Status: http.StatusInternalServerError,
+		}
+	}
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, problem Problem) {
+	problem.Instance = r.URL.Path
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	resp, _ := json.Marshal(problem)
+	w.Write(resp)
+}
+
+// writeError logs err and answers it as a problem. Client errors are logged at debug level.
+func (c *Company) writeError(w http.ResponseWriter, r *http.Request, method string, err error) {
+	log := c.logger.For(r.Context()).Named(fmt.Sprintf("%s:%s", errorSection, method))
+
+	// Nobody is left to answer to.
+	if errors.Is(err, context.Canceled) && r.Context().Err() != nil {
+		log.Debug(err.Error())
+		return
+	}
+
+	var scopeErr scopeError
+	if errors.As(err, &scopeErr) {
+		log.Debug(err.Error())
+		writeForbidden(w, r, scopeErr.scope)
+		return
+	}
+
+	problem := problemFromError(err)
+	trace.SpanFromContext(r.Context()).RecordError(err)
+
+	if problem.Status >= http.StatusInternalServerError {
+		log.Error(err.Error())
+	} else {
+		log.Debug(err.Error())
+	}
+
+	writeProblem(w, r, problem)
+}
@@ -0,0 +1,252 @@
+package http
+
+import (
+	"company-crud/internal/domain"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+	maxSearchLength  = 200
+)
+
+func listParamsFromQuery(query url.Values) (domain.CompanyListParams, error) {
+	params := domain.CompanyListParams{
+		Limit:  defaultListLimit,
+		Cursor: query.Get("cursor"),
+	}
+
+	var err error
+	if params.SortBy, params.Descending, err = sortFromQuery(query); err != nil {
+		return domain.CompanyListParams{}, err
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return domain.CompanyListParams{}, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+		params.Limit = limit
+	}
+
+	if params.Filter, err = filterFromQuery(query); err != nil {
+		return domain.CompanyListParams{}, err
+	}
+
+	return params, nil
+}
+
+func exportParamsFromQuery(query url.Values) (domain.CompanyExportParams, error) {
+	params := domain.CompanyExportParams{}
+
+	var err error
+	if params.SortBy, params.Descending, err = sortFromQuery(query); err != nil {
+		return domain.CompanyExportParams{}, err
+	}
+
+	if params.Filter, err = filterFromQuery(query); err != nil {
+		return domain.CompanyExportParams{}, err
+	}
+
+	return params, nil
+}
+
+// sortFromQuery reads the sort and order parameters, sorting by name by default.
+func sortFromQuery(query url.Values) (domain.CompanySort, bool, error) {
+	sortBy := domain.SortByName
+	if v := query.Get("sort"); v != "" {
+		var err error
+		sortBy, err = domain.GetCompSortFromString(v)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+		return sortBy, false, nil
+	case "desc":
+		return sortBy, true, nil
+	default:
+		return 0, false, fmt.Errorf("invalid order")
+	}
+}
+
+func filterFromQuery(query url.Values) (domain.CompanyFilter, error) {
+	filter := domain.CompanyFilter{}
+
+	if v := query.Get("type"); v != "" {
+		companyType, err := domain.GetCompTypeFromString(v)
+		if err != nil {
+			return domain.CompanyFilter{}, err
+		}
+		filter.Type = &companyType
+	}
+
+	includeDeleted, err := boolParam(query, "include_deleted")
+	if err != nil {
+		return domain.CompanyFilter{}, err
+	}
+	filter.IncludeDeleted = includeDeleted
+
+	if v := query.Get("registered"); v != "" {
+		registered, err := strconv.ParseBool(v)
+		if err != nil {
+			return domain.CompanyFilter{}, fmt.Errorf("invalid registered")
+		}
+		filter.IsRegistered = &registered
+	}
+
+	if filter.MinEmployees, err = intParam(query, "min_employees"); err != nil {
+		return domain.CompanyFilter{}, err
+	}
+	if filter.MaxEmployees, err = intParam(query, "max_employees"); err != nil {
+		return domain.CompanyFilter{}, err
+	}
+	if filter.CreatedAfter, err = timeParam(query, "created_after"); err != nil {
+		return domain.CompanyFilter{}, err
+	}
+	if filter.CreatedBefore, err = timeParam(query, "created_before"); err != nil {
+		return domain.CompanyFilter{}, err
+	}
+	if filter.UpdatedAfter, err = timeParam(query, "updated_after"); err != nil {
+		return domain.CompanyFilter{}, err
+	}
+	if filter.UpdatedBefore, err = timeParam(query, "updated_before"); err != nil {
+		return domain.CompanyFilter{}, err
+	}
+
+	return filter, nil
+}
+
+func searchParamsFromQuery(query url.Values) (domain.CompanySearchParams, error) {
+	params := domain.CompanySearchParams{
+		Text:   strings.TrimSpace(query.Get("q")),
+		Limit:  defaultListLimit,
+		Cursor: query.Get("cursor"),
+	}
+
+	if params.Text == "" {
+		return domain.CompanySearchParams{}, fmt.Errorf("q is required")
+	}
+	if utf8.RuneCountInString(params.Text) > maxSearchLength {
+		return domain.CompanySearchParams{}, fmt.Errorf("q must be at most %d characters", maxSearchLength)
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return domain.CompanySearchParams{}, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+		params.Limit = limit
+	}
+
+	if v := query.Get("type"); v != "" {
+		companyType, err := domain.GetCompTypeFromString(v)
+		if err != nil {
+			return domain.CompanySearchParams{}, err
+		}
+		params.Type = &companyType
+	}
+
+	if v := query.Get("registered"); v != "" {
+		registered, err := strconv.ParseBool(v)
+		if err != nil {
+			return domain.CompanySearchParams{}, fmt.Errorf("invalid registered")
+		}
+		params.IsRegistered = &registered
+	}
+
+	return params, nil
+}
+
+func auditParamsFromQuery(query url.Values) (domain.AuditListParams, error) {
+	params := domain.AuditListParams{
+		Limit:  defaultListLimit,
+		Cursor: query.Get("cursor"),
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return domain.AuditListParams{}, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+		params.Limit = limit
+	}
+
+	var err error
+	if params.Filter.From, err = timeParam(query, "from"); err != nil {
+		return domain.AuditListParams{}, err
+	}
+	if params.Filter.To, err = timeParam(query, "to"); err != nil {
+		return domain.AuditListParams{}, err
+	}
+
+	return params, nil
+}
+
+func revisionParamsFromQuery(query url.Values) (domain.RevisionListParams, error) {
+	params := domain.RevisionListParams{
+		Limit:  defaultListLimit,
+		Cursor: query.Get("cursor"),
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return domain.RevisionListParams{}, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+		params.Limit = limit
+	}
+
+	return params, nil
+}
+
+func boolParam(query url.Values, key string) (bool, error) {
+	v := query.Get(key)
+	if v == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s", key)
+	}
+
+	return b, nil
+}
+
+func intParam(query url.Values, key string) (*int, error) {
+	v := query.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", key)
+	}
+
+	return &i, nil
+}
+
+// timeParam expects RFC 3339 timestamps, e.g. 2024-12-15T17:10:16Z.
+func timeParam(query url.Values, key string) (*time.Time, error) {
+	v := query.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", key)
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,176 @@
+package http
+
+import (
+	"company-crud/internal/domain"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// @Summary      Revisions of a company
+// @Description  States every change left the company in, newest first.
+// @Tags         revision
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        company_name	path	string true "company_name"
+// @Param        limit			query	int		false	"page size, 1-100"
+// @Param        cursor			query	string	false	"next_cursor of the previous page"
+// @Success      200	{object}  RevisionList
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      404	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
+// @Router       /companies/{company_name}/revisions [get]
+func (c *Company) revisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	nameParam, err := nameParam(r)
+	if err != nil {
+		c.writeError(w, r, revisions, err)
+		return
+	}
+
+	params, err := revisionParamsFromQuery(r.URL.Query())
+	if err != nil {
+		c.writeError(w, r, revisions, paramError{err})
+		return
+	}
+
+	result, err := c.companyService.ListRevisions(r.Context(), nameParam, params)
+	if err != nil {
+		c.writeError(w, r, revisions, err)
+		return
+	}
+
+	revisionList := make([]Revision, 0, len(result.Revisions))
+	for _, revision := range result.Revisions {
+		revisionList = append(revisionList, revisionConverter(revision))
+	}
+
+	response, err := json.Marshal(RevisionList{
+		Revisions:  revisionList,
+		NextCursor: result.NextCursor,
+	})
+	if err != nil {
+		c.writeError(w, r, revisions, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(response)
+}
+
+// @Summary      Revision of a company
+// @Tags         revision
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        company_name	path	string true "company_name"
+// @Param        version		path	int true "version"
+// @Success      200	{object}  Revision
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      404	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
+// @Router       /companies/{company_name}/revisions/{version} [get]
+func (c *Company) revision(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	nameParam, err := nameParam(r)
+	if err != nil {
+		c.writeError(w, r, revision, err)
+		return
+	}
+
+	version, err := versionParam(r)
+	if err != nil {
+		c.writeError(w, r, revision, err)
+		return
+	}
+
+	result, err := c.companyService.GetRevision(r.Context(), nameParam, version)
+	if err != nil {
+		c.writeError(w, r, revision, err)
+		return
+	}
+
+	response, err := json.Marshal(revisionConverter(result))
+	if err != nil {
+		c.writeError(w, r, revision, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(response)
+}
+
+// @Summary      Revert company
+// @Description  Brings the company back to one of its revisions, as a new revision.
+// @Tags         revision
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        company_name	path	string true "company_name"
+// @Param        version		path	int true "version to revert to"
+// @Param        If-Match	header	string false "ETag the company is expected to be at"
+// @Success      200	{object}  Get
+// @Header       200	{string}  ETag	"current revision of the company"
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      404	{object}  Problem
+// @Failure      409	{object}  Problem
+// @Failure      412	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
+// @Router       /companies/{company_name}/revisions/{version}/revert [post]
+func (c *Company) revert(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	nameParam, err := nameParam(r)
+	if err != nil {
+		c.writeError(w, r, revert, err)
+		return
+	}
+
+	version, err := versionParam(r)
+	if err != nil {
+		c.writeError(w, r, revert, err)
+		return
+	}
+
+	precondition, err := ifMatch(r, func() (domain.Company, error) {
+		return c.companyService.Get(r.Context(), nameParam, false)
+	})
+	if err != nil {
+		c.writeError(w, r, revert, err)
+		return
+	}
+
+	result, err := c.companyService.Revert(r.Context(), nameParam, version, precondition)
+	if err != nil {
+		c.writeError(w, r, revert, err)
+		return
+	}
+
+	c.writeCompany(w, r, revert, result)
+}
+
+func versionParam(r *http.Request) (int, error) {
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil || version < 1 {
+		return 0, paramError{fmt.Errorf("invalid version")}
+	}
+
+	return version, nil
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// @Summary      Search companies
+// @Description  Finds the companies whose name or description match the words of q, stemmed, or whose name is close to q, which tolerates typos. Results come best match first, with the name and a description excerpt HTML escaped and their matched words wrapped in <mark> tags.
+// @Tags         company
+// @Accept       json
+// @Produce      json
+// @Security BearerAuth
+// @Param        q			query	string	true	"words, \"quoted phrases\", or and -excluded words"
+// @Param        type		query	string	false	"company type"
+// @Param        registered	query	bool	false	"registered"
+// @Param        limit		query	int		false	"page size, 1-100"
+// @Param        cursor		query	string	false	"next_cursor of the previous page"
+// @Success      200	{object}  SearchList
+// @Failure      400	{object}  Problem
+// @Failure      401	{object}  Problem
+// @Failure      403	{object}  Problem
+// @Failure      500	{object}  Problem
+// @Failure      503	{object}  Problem
+// @Failure      504	{object}  Problem
+// @Router       /companies/search [get]
+func (c *Company) search(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	params, err := searchParamsFromQuery(r.URL.Query())
+	if err != nil {
+		c.writeError(w, r, search, paramError{err})
+		return
+	}
+
+	result, err := c.companyService.Search(r.Context(), params)
+	if err != nil {
+		c.writeError(w, r, search, err)
+		return
+	}
+
+	results := make([]SearchResult, 0, len(result.Results))
+	for _, found := range result.Results {
+		results = append(results, SearchResult{
+			Company:       getConverter(found.Company),
+			Rank:          found.Rank,
+			NameHighlight: found.NameHighlight,
+			Snippet:       found.Snippet,
+		})
+	}
+
+	response, err := json.Marshal(SearchList{
+		Results:    results,
+		NextCursor: result.NextCursor,
+	})
+	if err != nil {
+		c.writeError(w, r, search, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(response)
+}
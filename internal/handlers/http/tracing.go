@@ -0,0 +1,72 @@
+package http
+
+import (
+	"company-crud/pkg/requestid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+const tracerName = "company-crud/internal/handlers/http"
+
+// statusWriter keeps the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+
+	return sw.ResponseWriter.Write(b)
+}
+
+// traced records a server span for every request, continuing the trace given in the
+// traceparent header if any.
+func traced(tracer trace.Tracer) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			route := r.URL.Path
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					route = template
+				}
+			}
+
+			ctx, span := tracer.Start(ctx, r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("http.route", route),
+					attribute.String("url.path", r.URL.Path),
+					attribute.String("http.request_id", requestid.FromContext(r.Context())),
+				),
+			)
+			defer span.End()
+
+			sw := &statusWriter{ResponseWriter: w}
+			h.ServeHTTP(sw, r.WithContext(ctx))
+
+			if sw.status == 0 {
+				sw.status = http.StatusOK
+			}
+			span.SetAttributes(attribute.Int("http.response.status_code", sw.status))
+			if sw.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(sw.status))
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/internal/events"
+	"company-crud/pkg/postres"
+	"company-crud/pkg/requestid"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"strconv"
+	"strings"
+)
+
+// insertAudit records who made a company change within the transaction of the change.
+// before is nil for creations and after for deletions.
+func insertAudit(ctx context.Context, tx *sqlx.Tx, operation domain.AuditOperation, before, after *model) error {
+	current := after
+	if current == nil {
+		current = before
+	}
+
+	beforeSnapshot, err := auditSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterSnapshot, err := auditSnapshot(after)
+	if err != nil {
+		return err
+	}
+
+	principal, _ := domain.PrincipalFrom(ctx)
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO xm_assessment.company_audit (company_id, company_name, operation, actor, request_id, before, after)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		current.ID, current.Name, string(operation), principal.Subject, requestid.FromContext(ctx), beforeSnapshot, afterSnapshot)
+
+	return err
+}
+
+// auditSnapshot is the JSON document of m, as a string for the JSONB column, or nil.
+func auditSnapshot(m *model) (*string, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	company, err := domainConverter(*m)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(events.Snapshot(company))
+	if err != nil {
+		return nil, err
+	}
+	snapshot := string(raw)
+
+	return &snapshot, nil
+}
+
+func (u *Company) ListAudit(ctx context.Context, params domain.AuditListParams) (domain.AuditPage, error) {
+	query, args, err := auditQueryBuilder(params)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, listAudit)).Error(err.Error())
+		return domain.AuditPage{}, err
+	}
+
+	var auditModels []auditModel
+	err = u.db.SelectContext(ctx, &auditModels, u.db.Rebind(query), args...)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, listAudit)).Error(err.Error())
+		return domain.AuditPage{}, postres.ContextErr(ctx, err)
+	}
+
+	page := domain.AuditPage{}
+	if len(auditModels) > params.Limit {
+		auditModels = auditModels[:params.Limit]
+		page.NextCursor = encodeSeqCursor(auditModels[len(auditModels)-1].ID)
+	}
+
+	page.Entries = make([]domain.AuditEntry, 0, len(auditModels))
+	for _, m := range auditModels {
+		page.Entries = append(page.Entries, domain.AuditEntry{
+			ID:          m.ID,
+			CompanyID:   m.CompanyID,
+			CompanyName: m.CompanyName,
+			Operation:   domain.AuditOperation(m.Operation),
+			Actor:       m.Actor,
+			RequestID:   m.RequestID,
+			Before:      m.Before,
+			After:       m.After,
+			CreatedAt:   m.CreatedAt,
+		})
+	}
+
+	return page, nil
+}
+
+// auditQueryBuilder returns the select statement, with `?` bind vars, and its arguments.
+// Entries are ordered by id, which follows the commit order closely enough and is unique.
+func auditQueryBuilder(params domain.AuditListParams) (string, []interface{}, error) {
+	if params.Limit <= 0 {
+		return "", nil, postres.InvalidArgumentsForBuildingquery
+	}
+
+	var conditions []string
+	var args []interface{}
+
+	filter := params.Filter
+	if filter.CompanyName != "" {
+		conditions = append(conditions, `company_id IN (SELECT company_id FROM xm_assessment.company_audit WHERE company_name = ?)`)
+		args = append(args, filter.CompanyName)
+	}
+
+	if filter.From != nil {
+		conditions = append(conditions, `created_at >= ?`)
+		args = append(args, *filter.From)
+	}
+
+	if filter.To != nil {
+		conditions = append(conditions, `created_at < ?`)
+		args = append(args, *filter.To)
+	}
+
+	if params.Cursor != "" {
+		id, err := decodeSeqCursor(params.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+
+		conditions = append(conditions, `id < ?`)
+		args = append(args, id)
+	}
+
+	query := `SELECT id, company_id, company_name, operation, actor, request_id, before, after, created_at FROM xm_assessment.company_audit`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, params.Limit+1)
+
+	return query, args, nil
+}
+
+// encodeSeqCursor builds the cursor of a page ordered by a sequence number, the id of the
+// audit entries or the version of the revisions.
+func encodeSeqCursor(lastID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(lastID, 10)))
+}
+
+func decodeSeqCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, postres.InvalidCursor
+	}
+
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, postres.InvalidCursor
+	}
+
+	return id, nil
+}
@@ -0,0 +1,278 @@
+package db
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/internal/events"
+	"company-crud/pkg/postres"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"strings"
+)
+
+var (
+	// batchItemFailed rolls an atomic batch back once one of its items failed.
+	batchItemFailed = errors.New("batch item failed")
+	// batchDryRun rolls a dry run batch back once applied.
+	batchDryRun = errors.New("batch dry run")
+)
+
+// batchChange is a change made by a batch item, recorded once the item succeeded.
+type batchChange struct {
+	operation domain.AuditOperation
+	before    *model
+	after     *model
+}
+
+func (bc batchChange) id() uuid.UUID {
+	if bc.after != nil {
+		return bc.after.ID
+	}
+
+	return bc.before.ID
+}
+
+// Batch applies the items in order, in one transaction. Consecutive creations are inserted
+// by a single statement. Outside atomic batches every other item runs in a savepoint, so
+// that its failure doesn't abort the transaction.
+func (u *Company) Batch(ctx context.Context, batch domain.Batch) ([]domain.BatchResult, error) {
+	results := make([]domain.BatchResult, len(batch.Items))
+
+	err := u.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		var summary []events.Change
+
+		for start := 0; start < len(batch.Items); {
+			end := start + 1
+			var changes []batchChange
+			var err error
+			if batch.Items[start].Op == domain.BatchCreate {
+				for end < len(batch.Items) && batch.Items[end].Op == domain.BatchCreate {
+					end++
+				}
+				changes, err = batchCreate(ctx, tx, batch.Items[start:end], results[start:end])
+			} else {
+				changes, err = batchUpdate(ctx, tx, batch.Items[start], &results[start], batch.Atomic)
+			}
+			if err != nil {
+				return err
+			}
+
+			if batch.Atomic {
+				for _, result := range results[start:end] {
+					if result.Err != nil {
+						return batchItemFailed
+					}
+				}
+			}
+
+			for _, change := range changes {
+				if !batch.SummaryEvent {
+					if err := recordChange(ctx, tx, change.operation, change.before, change.after); err != nil {
+						return err
+					}
+					continue
+				}
+
+				if err := recordHistory(ctx, tx, change.operation, change.before, change.after); err != nil {
+					return err
+				}
+				eventChange, err := newEventChange(change)
+				if err != nil {
+					return err
+				}
+				summary = append(summary, eventChange)
+			}
+
+			start = end
+		}
+
+		if len(summary) > 0 {
+			if err := insertBatchEvent(ctx, tx, summary); err != nil {
+				return err
+			}
+		}
+
+		if batch.DryRun {
+			return batchDryRun
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, batchDryRun) {
+			return results, nil
+		}
+		if errors.Is(err, batchItemFailed) {
+			for i := range results {
+				if results[i].Err == nil {
+					results[i] = domain.BatchResult{Err: postres.NotApplied}
+				}
+			}
+			return results, nil
+		}
+
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, applyBatch)).Error(err.Error())
+		return nil, postres.ContextErr(ctx, err)
+	}
+
+	return results, nil
+}
+
+// batchCreate inserts the companies of items with one statement. Those whose name is taken,
+// by an existing company or an earlier item, are skipped and fail with DuplicateKey.
+func batchCreate(ctx context.Context, tx *sqlx.Tx, items []domain.BatchItem, results []domain.BatchResult) ([]batchChange, error) {
+	values := make([]string, 0, len(items))
+	args := make([]interface{}, 0, 5*len(items))
+	for _, item := range items {
+		companyModel := modelConverter(item.Company)
+		n := len(args)
+		values = append(values, fmt.Sprintf(`($%d, $%d, $%d, $%d, $%d, NOW())`, n+1, n+2, n+3, n+4, n+5))
+		args = append(args,
+			companyModel.Name,
+			companyModel.Description,
+			companyModel.EmployeesNumber,
+			companyModel.IsRegistered,
+			companyModel.Type,
+		)
+	}
+
+	var inserted []model
+	err := tx.SelectContext(ctx, &inserted,
+		`INSERT INTO xm_assessment.companies (name, description, employees_number, is_registered, type, updated_at)
+		 VALUES `+strings.Join(values, `, `)+`
+		 ON CONFLICT (name) WHERE deleted_at IS NULL DO NOTHING
+		 RETURNING `+companyColumns,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]model, len(inserted))
+	for _, m := range inserted {
+		byName[m.Name] = m
+	}
+
+	changes := make([]batchChange, 0, len(inserted))
+	for i, item := range items {
+		m, ok := byName[item.Company.Name]
+		if !ok {
+			results[i].Err = postres.DuplicateKey
+			continue
+		}
+		// Later items with the same name were the ones skipped.
+		delete(byName, item.Company.Name)
+
+		results[i].ID = m.ID
+		results[i].Created = true
+		changes = append(changes, batchChange{operation: domain.AuditCreate, after: &m})
+	}
+
+	return changes, nil
+}
+
+// batchUpdate patches or deletes the company of item. The errors failing the item alone are
+// set on result, the others returned.
+func batchUpdate(ctx context.Context, tx *sqlx.Tx, item domain.BatchItem, result *domain.BatchResult, atomic bool) ([]batchChange, error) {
+	if !atomic {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT batch_item`); err != nil {
+			return nil, err
+		}
+	}
+
+	change, err := batchApply(ctx, tx, item)
+	if err != nil {
+		itemErr := batchItemErr(err)
+		if itemErr == nil {
+			return nil, err
+		}
+		result.Err = itemErr
+
+		if !atomic {
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT batch_item`); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+
+	if !atomic {
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT batch_item`); err != nil {
+			return nil, err
+		}
+	}
+
+	result.ID = change.id()
+	result.Created = change.operation == domain.AuditCreate
+
+	return []batchChange{change}, nil
+}
+
+// batchApply makes the change of a patch, delete or upsert item.
+func batchApply(ctx context.Context, tx *sqlx.Tx, item domain.BatchItem) (batchChange, error) {
+	switch item.Op {
+	case domain.BatchPatch, domain.BatchUpsert:
+		companyModel := modelConverter(item.Company)
+		companyModel.CurrentName = item.Name
+
+		before, after, err := patchTx(ctx, tx, item.Company, companyModel, `name=:current_name`, nil)
+		if item.Op == domain.BatchUpsert && errors.Is(err, sql.ErrNoRows) {
+			results := make([]domain.BatchResult, 1)
+			changes, err := batchCreate(ctx, tx, []domain.BatchItem{item}, results)
+			if err != nil {
+				return batchChange{}, err
+			}
+			if results[0].Err != nil {
+				return batchChange{}, results[0].Err
+			}
+
+			return changes[0], nil
+		}
+
+		return batchChange{operation: domain.AuditUpdate, before: &before, after: &after}, err
+	case domain.BatchDelete:
+		deleted, err := deleteTx(ctx, tx, `name`, item.Name, nil)
+		return batchChange{operation: domain.AuditDelete, before: &deleted}, err
+	default:
+		return batchChange{}, fmt.Errorf("unknown batch op %q", item.Op)
+	}
+}
+
+// batchItemErr maps the errors failing a batch item alone, it is nil for those failing the
+// whole batch.
+func batchItemErr(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return postres.NoRowsErr
+	case isDuplicateKey(err), errors.Is(err, postres.DuplicateKey):
+		return postres.DuplicateKey
+	case errors.Is(err, postres.InvalidArgumentsForBuildingquery):
+		return err
+	default:
+		return nil
+	}
+}
+
+// newEventChange is the entry of change in the event summing up a batch.
+func newEventChange(change batchChange) (events.Change, error) {
+	current := change.after
+	var before *domain.Company
+	if current == nil {
+		current = change.before
+	} else if change.before != nil {
+		b, err := domainConverter(*change.before)
+		if err != nil {
+			return events.Change{}, err
+		}
+		before = &b
+	}
+
+	company, err := domainConverter(*current)
+	if err != nil {
+		return events.Change{}, err
+	}
+
+	return events.NewChange(eventType(change.operation), company, before), nil
+}
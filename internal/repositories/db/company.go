@@ -2,23 +2,43 @@ package db
 
 import (
 	"company-crud/internal/domain"
+	"company-crud/internal/events"
 	"company-crud/pkg/logger"
 	"company-crud/pkg/postres"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	"time"
 )
 
 const errorSection = "companyDB"
 const (
-	create       = "create"
-	getByName    = "getByName"
-	patchByName  = "patchByName"
-	deleteByName = "deleteByName"
+	create        = "create"
+	getByName     = "getByName"
+	patchByName   = "patchByName"
+	deleteByName  = "deleteByName"
+	getByID       = "getByID"
+	patchByID     = "patchByID"
+	deleteByID    = "deleteByID"
+	list          = "list"
+	listAudit     = "listAudit"
+	restore       = "restore"
+	purge         = "purge"
+	getAsOf       = "getAsOf"
+	listRevisions = "listRevisions"
+	getRevision   = "getRevision"
+	revert        = "revert"
+	applyBatch    = "applyBatch"
+	export        = "export"
+	search        = "search"
 )
 
+const companyColumns = `id, name, description, employees_number, is_registered, type, created_at, updated_at, version, deleted_at`
+
 type Company struct {
 	db     *postres.Postgres
 	logger *logger.Logger
@@ -31,126 +51,338 @@ func New(db *postres.Postgres, log *logger.Logger) *Company {
 	}
 }
 
-func (u *Company) Insert(company domain.Company) (uuid.UUID, error) {
+func (u *Company) Insert(ctx context.Context, company domain.Company) (uuid.UUID, error) {
 	companyModel := modelConverter(company)
 
-	err := u.db.QueryRow(
-		`INSERT INTO xm_assessment.companies (name, description, employees_number, is_registered, type, updated_at)
-			 VALUES ($1, $2, $3, $4, $5, $6)
-			 RETURNING id`,
-		companyModel.Name,
-		companyModel.Description,
-		companyModel.EmployeesNumber,
-		companyModel.IsRegistered,
-		companyModel.Type,
-		companyModel.UpdatedAt,
-	).Scan(&companyModel.ID)
+	err := u.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		inserted := model{}
+		err := tx.GetContext(ctx, &inserted,
+			`INSERT INTO xm_assessment.companies (name, description, employees_number, is_registered, type, updated_at)
+			 VALUES ($1, $2, $3, $4, $5, NOW())
+			 RETURNING `+companyColumns,
+			companyModel.Name,
+			companyModel.Description,
+			companyModel.EmployeesNumber,
+			companyModel.IsRegistered,
+			companyModel.Type,
+		)
+		if err != nil {
+			return err
+		}
+		companyModel.ID = inserted.ID
+
+		return recordChange(ctx, tx, domain.AuditCreate, nil, &inserted)
+	})
 
 	if err != nil {
-		u.logger.Named(fmt.Sprintf("%s:%s", errorSection, create)).Error(err.Error())
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, create)).Error(err.Error())
 
-		var pgErr *pq.Error
-		ok := errors.As(err, &pgErr)
-		if ok {
-			if pgErr.Code == "23505" {
-				return uuid.UUID{}, postres.DuplicateKey
-			}
+		if isDuplicateKey(err) {
+			return uuid.UUID{}, postres.DuplicateKey
 		}
 
-		return uuid.Nil, err
+		return uuid.Nil, postres.ContextErr(ctx, err)
 	}
 	return companyModel.ID, nil
 }
 
-func (u *Company) GetByName(name string) (domain.Company, error) {
-	companyModel := model{
-		Name: name,
-	}
+func (u *Company) GetByName(ctx context.Context, name string) (domain.Company, error) {
+	return u.getBy(ctx, getByName, `name`, name, false)
+}
 
-	query := `SELECT * FROM xm_assessment.companies WHERE name = $1`
+func (u *Company) GetByNameIncludingDeleted(ctx context.Context, name string) (domain.Company, error) {
+	return u.getBy(ctx, getByName, `name`, name, true)
+}
 
-	var tempType string
-	err := u.db.QueryRow(query, companyModel.Name).Scan(&companyModel.ID, &companyModel.Name, &companyModel.Description, &companyModel.EmployeesNumber, &companyModel.IsRegistered, &tempType, &companyModel.CreatedAt, &companyModel.UpdatedAt)
+func (u *Company) GetByID(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	return u.getBy(ctx, getByID, `id`, id, false)
+}
+
+func (u *Company) GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	return u.getBy(ctx, getByID, `id`, id, true)
+}
+
+// getBy returns the company matching value. Including the deleted ones, several can share
+// a name: the one not deleted comes first, then the one deleted last.
+func (u *Company) getBy(ctx context.Context, method, column string, value interface{}, includeDeleted bool) (domain.Company, error) {
+	companyModel := model{}
+
+	query := fmt.Sprintf(`SELECT %s FROM xm_assessment.companies WHERE %s = $1`, companyColumns, column)
+	if includeDeleted {
+		query += ` ORDER BY deleted_at DESC NULLS FIRST LIMIT 1`
+	} else {
+		query += ` AND deleted_at IS NULL`
+	}
+
+	err := u.db.GetContext(ctx, &companyModel, query, value)
 	if err != nil {
-		u.logger.Named(fmt.Sprintf("%s:%s", errorSection, getByName)).Error(err.Error())
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, method)).Error(err.Error())
 		if errors.Is(err, sql.ErrNoRows) {
 			return domain.Company{}, postres.NoRowsErr
 		}
-		return domain.Company{}, err
+		return domain.Company{}, postres.ContextErr(ctx, err)
 	}
 
-	companyType, err := domain.GetCompTypeFromString(tempType)
+	company, err := domainConverter(companyModel)
 	if err != nil {
-		u.logger.Named(fmt.Sprintf("%s:%s", errorSection, getByName)).Error(err.Error())
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, method)).Error(err.Error())
 		return domain.Company{}, err
 	}
 
-	return domain.Company{
-		ID:              companyModel.ID,
-		Name:            companyModel.Name,
-		Description:     &companyModel.Description,
-		EmployeesNumber: &companyModel.EmployeesNumber,
-		IsRegistered:    &companyModel.IsRegistered,
-		Type:            &companyType,
-		UpdatedAt:       companyModel.UpdatedAt,
-		CreatedAt:       companyModel.CreatedAt,
-	}, nil
+	return company, nil
+}
+
+func (u *Company) DeleteByName(ctx context.Context, name string, precondition *domain.Precondition) error {
+	return u.deleteBy(ctx, deleteByName, `name`, name, precondition)
+}
+
+func (u *Company) DeleteByID(ctx context.Context, id uuid.UUID, precondition *domain.Precondition) error {
+	return u.deleteBy(ctx, deleteByID, `id`, id, precondition)
 }
 
-func (u *Company) DeleteByName(name string) error {
-	query := `DELETE FROM xm_assessment.companies WHERE name=$1`
+func (u *Company) deleteBy(ctx context.Context, method, column string, value interface{}, precondition *domain.Precondition) error {
+	err := u.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		deleted, err := deleteTx(ctx, tx, column, value, precondition)
+		if err != nil {
+			return err
+		}
 
-	res, err := u.db.Exec(query, name)
+		return recordChange(ctx, tx, domain.AuditDelete, &deleted, nil)
+	})
 	if err != nil {
-		u.logger.Named(fmt.Sprintf("%s:%s", errorSection, deleteByName)).Error(err.Error())
-		return err
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, method)).Error(err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			return noRowsErr(precondition)
+		}
+		return postres.ContextErr(ctx, err)
 	}
 
-	rowsNumber, err := res.RowsAffected()
+	return nil
+}
+
+func (u *Company) PatchByName(ctx context.Context, company domain.Company, currentName string, precondition *domain.Precondition) error {
+	companyModel := modelConverter(company)
+	companyModel.CurrentName = currentName
+
+	return u.patchBy(ctx, patchByName, company, companyModel, `name=:current_name`, precondition)
+}
+
+func (u *Company) PatchByID(ctx context.Context, company domain.Company, id uuid.UUID, precondition *domain.Precondition) error {
+	companyModel := modelConverter(company)
+	companyModel.ID = id
+
+	return u.patchBy(ctx, patchByID, company, companyModel, `id=:id`, precondition)
+}
+
+func (u *Company) patchBy(ctx context.Context, method string, company domain.Company, companyModel model, condition string, precondition *domain.Precondition) error {
+	err := u.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		before, after, err := patchTx(ctx, tx, company, companyModel, condition, precondition)
+		if err != nil {
+			return err
+		}
+
+		return recordChange(ctx, tx, domain.AuditUpdate, &before, &after)
+	})
 	if err != nil {
-		u.logger.Named(fmt.Sprintf("%s:%s", errorSection, deleteByName)).Error(err.Error())
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, method)).Error(err.Error())
 		if errors.Is(err, sql.ErrNoRows) {
-			return postres.NoRowsErr
+			return noRowsErr(precondition)
 		}
-		return err
+		if isDuplicateKey(err) {
+			return postres.DuplicateKey
+		}
+		return postres.ContextErr(ctx, err)
 	}
 
-	if rowsNumber == 0 {
-		return postres.NoRowsErr
+	return nil
+}
+
+func (u *Company) Restore(ctx context.Context, name string) error {
+	err := u.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		before := model{}
+		err := tx.GetContext(ctx, &before,
+			`SELECT `+companyColumns+` FROM xm_assessment.companies
+			 WHERE name=$1 AND deleted_at IS NOT NULL
+			 ORDER BY deleted_at DESC LIMIT 1 FOR UPDATE`,
+			name)
+		if err != nil {
+			return err
+		}
+
+		after := model{}
+		err = tx.GetContext(ctx, &after,
+			`UPDATE xm_assessment.companies SET deleted_at=NULL, updated_at=NOW(), version=version+1
+			 WHERE id=$1 RETURNING `+companyColumns,
+			before.ID)
+		if err != nil {
+			return err
+		}
+
+		return recordChange(ctx, tx, domain.AuditRestore, &before, &after)
+	})
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, restore)).Error(err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			return postres.NoRowsErr
+		}
+		// Another company took the name meanwhile.
+		if isDuplicateKey(err) {
+			return postres.DuplicateKey
+		}
+		return postres.ContextErr(ctx, err)
 	}
 
 	return nil
 }
 
-func (u *Company) PatchByName(company domain.Company, currentName string) error {
+// Purge hard deletes the companies deleted before deletedBefore, oldest first. Rows locked
+// by a concurrent purge are skipped. The audit log keeps track of them.
+func (u *Company) Purge(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	purged := 0
+
+	err := u.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		var removed []model
+		err := tx.SelectContext(ctx, &removed,
+			`DELETE FROM xm_assessment.companies WHERE id IN (
+				SELECT id FROM xm_assessment.companies
+				WHERE deleted_at < $1
+				ORDER BY deleted_at LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			 ) RETURNING `+companyColumns,
+			deletedBefore, limit)
+		if err != nil {
+			return err
+		}
+
+		for i := range removed {
+			if err := insertAudit(ctx, tx, domain.AuditPurge, &removed[i], nil); err != nil {
+				return err
+			}
+		}
+		purged = len(removed)
+
+		return nil
+	})
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, purge)).Error(err.Error())
+		return 0, postres.ContextErr(ctx, err)
+	}
+
+	return purged, nil
+}
+
+// deleteTx marks the company matching value deleted, it stays restorable until purged.
+func deleteTx(ctx context.Context, tx *sqlx.Tx, column string, value interface{}, precondition *domain.Precondition) (model, error) {
+	query := fmt.Sprintf(`UPDATE xm_assessment.companies SET deleted_at=NOW(), updated_at=NOW(), version=version+1
+		WHERE %s=$1 AND deleted_at IS NULL`, column)
+	args := []interface{}{value}
+	if precondition != nil {
+		query += ` AND id=$2 AND version=$3`
+		args = append(args, precondition.ID, precondition.Version)
+	}
+	query += ` RETURNING ` + companyColumns
+
+	deleted := model{}
+	err := tx.GetContext(ctx, &deleted, query, args...)
+
+	return deleted, err
+}
+
+// patchTx updates the fields set on company for the row matching condition, whose named
+// parameters are bound from companyModel, and returns the row before and after.
+func patchTx(ctx context.Context, tx *sqlx.Tx, company domain.Company, companyModel model, condition string, precondition *domain.Precondition) (model, model, error) {
 	affectedFields, err := patchQueryBuilder(company)
 	if err != nil {
-		u.logger.Named(fmt.Sprintf("%s:%s", errorSection, patchByName)).Error(err.Error())
-		return err
+		return model{}, model{}, err
 	}
 
-	companyModel := modelConverter(company)
-	companyModel.CurrentName = currentName
+	condition += ` AND deleted_at IS NULL`
+	if precondition != nil {
+		condition += ` AND id=:expected_id AND version=:expected_version`
+		companyModel.ExpectedID = precondition.ID
+		companyModel.ExpectedVersion = precondition.Version
+	}
+
+	// The row is locked first so that the event carries the exact state it was changed from.
+	selectQuery, selectArgs, err := sqlx.Named(
+		fmt.Sprintf(`SELECT %s FROM xm_assessment.companies WHERE %s FOR UPDATE`, companyColumns, condition),
+		companyModel,
+	)
+	if err != nil {
+		return model{}, model{}, err
+	}
 
-	query := fmt.Sprintf(`UPDATE xm_assessment.companies SET %s WHERE name=:current_name`, affectedFields)
+	before := model{}
+	if err := tx.GetContext(ctx, &before, tx.Rebind(selectQuery), selectArgs...); err != nil {
+		return model{}, model{}, err
+	}
 
-	result, err := u.db.NamedExec(query, companyModel)
+	companyModel.ID = before.ID
+	updateQuery, updateArgs, err := sqlx.Named(
+		fmt.Sprintf(`UPDATE xm_assessment.companies SET %s WHERE id=:id RETURNING %s`, affectedFields, companyColumns),
+		companyModel,
+	)
 	if err != nil {
-		u.logger.Named(fmt.Sprintf("%s:%s", errorSection, patchByName)).Error(err.Error())
+		return model{}, model{}, err
+	}
+
+	after := model{}
+	if err := tx.GetContext(ctx, &after, tx.Rebind(updateQuery), updateArgs...); err != nil {
+		return model{}, model{}, err
+	}
+
+	return before, after, nil
+}
+
+// recordChange writes the event, the audit entry and the revision of a company change
+// within its transaction. before is nil for creations and after for deletions.
+func recordChange(ctx context.Context, tx *sqlx.Tx, operation domain.AuditOperation, before, after *model) error {
+	if after == nil {
+		if err := insertEvent(ctx, tx, eventType(operation), *before, nil); err != nil {
+			return err
+		}
+	} else if err := insertEvent(ctx, tx, eventType(operation), *after, before); err != nil {
 		return err
 	}
 
-	rowsNumber, err := result.RowsAffected()
-	if err != nil {
-		u.logger.Named(fmt.Sprintf("%s:%s", errorSection, patchByName)).Error(err.Error())
+	return recordHistory(ctx, tx, operation, before, after)
+}
+
+// recordHistory writes the audit entry and the revision of a company change, for changes
+// whose event is written apart.
+func recordHistory(ctx context.Context, tx *sqlx.Tx, operation domain.AuditOperation, before, after *model) error {
+	if err := insertAudit(ctx, tx, operation, before, after); err != nil {
 		return err
 	}
 
-	if rowsNumber == 0 {
-		return postres.NoRowsErr
+	if after == nil {
+		return insertRevision(ctx, tx, operation, *before)
 	}
 
-	return nil
+	return insertRevision(ctx, tx, operation, *after)
+}
+
+// eventType is the type of the event published about a change.
+func eventType(operation domain.AuditOperation) events.Type {
+	switch operation {
+	case domain.AuditCreate:
+		return events.CompanyCreated
+	case domain.AuditDelete:
+		return events.CompanyDeleted
+	case domain.AuditRestore:
+		return events.CompanyRestored
+	default:
+		return events.CompanyUpdated
+	}
+}
+
+// noRowsErr tells apart a missing row from one that changed since the precondition was taken.
+func noRowsErr(precondition *domain.Precondition) error {
+	if precondition != nil {
+		return postres.PreconditionFailed
+	}
+
+	return postres.NoRowsErr
 }
 
 func patchQueryBuilder(company domain.Company) (string, error) {
@@ -177,7 +409,16 @@ func patchQueryBuilder(company domain.Company) (string, error) {
 	if query == "" {
 		return query, postres.InvalidArgumentsForBuildingquery
 	}
-	query += ` updated_at=:updated_at`
+	query += ` updated_at=NOW(), version=version+1`
 
 	return query, nil
 }
+
+func isDuplicateKey(err error) bool {
+	var pgErr *pq.Error
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+
+	return false
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/pkg/postres"
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"strings"
+)
+
+// exportFetchSize is the number of rows fetched from the export cursor at a time, which
+// bounds the memory an export takes whatever the size of the table.
+const exportFetchSize = 500
+
+// Export reads the companies through a server side cursor, within a repeatable read
+// transaction so that the export is consistent however long it takes.
+func (u *Company) Export(ctx context.Context, params domain.CompanyExportParams, fn func(domain.Company) error) error {
+	query, args, err := exportQueryBuilder(params)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, export)).Error(err.Error())
+		return err
+	}
+
+	err = u.db.InSnapshot(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DECLARE company_export NO SCROLL CURSOR FOR `+tx.Rebind(query), args...); err != nil {
+			return err
+		}
+
+		for {
+			fetched, err := fetchExport(ctx, tx, fn)
+			if err != nil {
+				return err
+			}
+			if fetched < exportFetchSize {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, export)).Error(err.Error())
+		return postres.ContextErr(ctx, err)
+	}
+
+	return nil
+}
+
+// fetchExport calls fn with the next rows of the export cursor and returns how many there were.
+func fetchExport(ctx context.Context, tx *sqlx.Tx, fn func(domain.Company) error) (int, error) {
+	rows, err := tx.QueryxContext(ctx, fmt.Sprintf(`FETCH FORWARD %d FROM company_export`, exportFetchSize))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	fetched := 0
+	for rows.Next() {
+		companyModel := model{}
+		if err := rows.StructScan(&companyModel); err != nil {
+			return fetched, err
+		}
+
+		company, err := domainConverter(companyModel)
+		if err != nil {
+			return fetched, err
+		}
+
+		if err := fn(company); err != nil {
+			return fetched, err
+		}
+		fetched++
+	}
+
+	return fetched, rows.Err()
+}
+
+// exportQueryBuilder returns the select statement, with `?` bind vars, and its arguments.
+func exportQueryBuilder(params domain.CompanyExportParams) (string, []interface{}, error) {
+	sortColumn := params.SortBy.String()
+	if sortColumn == "" {
+		return "", nil, postres.InvalidArgumentsForBuildingquery
+	}
+
+	conditions, args := filterConditions(params.Filter)
+
+	direction := "ASC"
+	if params.Descending {
+		direction = "DESC"
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM xm_assessment.companies`, companyColumns)
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	query += fmt.Sprintf(` ORDER BY %s %s, id %s`, sortColumn, direction, direction)
+
+	return query, args, nil
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/pkg/logger"
+	"company-crud/pkg/postres"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const idempotencyErrorSection = "idempotencyDB"
+const (
+	reserve  = "reserve"
+	complete = "complete"
+	release  = "release"
+)
+
+type Idempotency struct {
+	db     *postres.Postgres
+	logger *logger.Logger
+}
+
+func NewIdempotency(db *postres.Postgres, log *logger.Logger) *Idempotency {
+	return &Idempotency{
+		db:     db,
+		logger: log,
+	}
+}
+
+func (i *Idempotency) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (domain.IdempotencyRecord, bool, error) {
+	// An expired record is taken over as if the key had never been used.
+	query := `INSERT INTO xm_assessment.idempotency_keys (key, request_hash, expires_at)
+			  VALUES ($1, $2, NOW() + make_interval(secs => $3))
+			  ON CONFLICT (key) DO UPDATE
+			  SET request_hash = EXCLUDED.request_hash, status_code = NULL, headers = NULL, response_body = NULL,
+			      created_at = NOW(), expires_at = EXCLUDED.expires_at
+			  WHERE idempotency_keys.expires_at < NOW()
+			  RETURNING key`
+
+	var reservedKey string
+	err := i.db.QueryRowContext(ctx, query, key, requestHash, ttl.Seconds()).Scan(&reservedKey)
+	if err == nil {
+		return domain.IdempotencyRecord{}, true, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		i.logger.For(ctx).Named(fmt.Sprintf("%s:%s", idempotencyErrorSection, reserve)).Error(err.Error())
+		return domain.IdempotencyRecord{}, false, postres.ContextErr(ctx, err)
+	}
+
+	recordModel := idempotencyModel{}
+	err = i.db.GetContext(ctx, &recordModel, `SELECT key, request_hash, status_code, headers, response_body, created_at
+			  FROM xm_assessment.idempotency_keys WHERE key = $1`, key)
+	if err != nil {
+		i.logger.For(ctx).Named(fmt.Sprintf("%s:%s", idempotencyErrorSection, reserve)).Error(err.Error())
+		return domain.IdempotencyRecord{}, false, postres.ContextErr(ctx, err)
+	}
+
+	record := domain.IdempotencyRecord{
+		Key:         recordModel.Key,
+		RequestHash: recordModel.RequestHash,
+		StatusCode:  int(recordModel.StatusCode.Int64),
+		Body:        recordModel.ResponseBody,
+		CreatedAt:   recordModel.CreatedAt,
+	}
+
+	if recordModel.Headers != nil {
+		if err := json.Unmarshal(recordModel.Headers, &record.Headers); err != nil {
+			i.logger.For(ctx).Named(fmt.Sprintf("%s:%s", idempotencyErrorSection, reserve)).Error(err.Error())
+			return domain.IdempotencyRecord{}, false, err
+		}
+	}
+
+	return record, false, nil
+}
+
+func (i *Idempotency) Complete(ctx context.Context, key string, statusCode int, headers map[string]string, body []byte) error {
+	rawHeaders, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+
+	_, err = i.db.ExecContext(ctx, `UPDATE xm_assessment.idempotency_keys SET status_code = $2, headers = $3, response_body = $4 WHERE key = $1`,
+		key, statusCode, string(rawHeaders), body)
+	if err != nil {
+		i.logger.For(ctx).Named(fmt.Sprintf("%s:%s", idempotencyErrorSection, complete)).Error(err.Error())
+		return postres.ContextErr(ctx, err)
+	}
+
+	return nil
+}
+
+func (i *Idempotency) Release(ctx context.Context, key string) error {
+	_, err := i.db.ExecContext(ctx, `DELETE FROM xm_assessment.idempotency_keys WHERE key = $1 AND status_code IS NULL`, key)
+	if err != nil {
+		i.logger.For(ctx).Named(fmt.Sprintf("%s:%s", idempotencyErrorSection, release)).Error(err.Error())
+		return postres.ContextErr(ctx, err)
+	}
+
+	return nil
+}
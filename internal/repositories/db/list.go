@@ -0,0 +1,195 @@
+package db
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/pkg/postres"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// cursor is the keyset position of the last row of a page. It carries the sort it
+// was produced with, so it can't be replayed against a different ordering.
+type cursor struct {
+	Sort       string    `json:"s"`
+	Descending bool      `json:"d"`
+	Value      string    `json:"v"`
+	ID         uuid.UUID `json:"id"`
+}
+
+func (u *Company) List(ctx context.Context, params domain.CompanyListParams) (domain.CompanyPage, error) {
+	query, args, err := listQueryBuilder(params)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, list)).Error(err.Error())
+		return domain.CompanyPage{}, err
+	}
+
+	var companyModels []model
+	err = u.db.SelectContext(ctx, &companyModels, u.db.Rebind(query), args...)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, list)).Error(err.Error())
+		return domain.CompanyPage{}, postres.ContextErr(ctx, err)
+	}
+
+	page := domain.CompanyPage{}
+	if len(companyModels) > params.Limit {
+		companyModels = companyModels[:params.Limit]
+		page.NextCursor = encodeCursor(params, companyModels[len(companyModels)-1])
+	}
+
+	page.Companies = make([]domain.Company, 0, len(companyModels))
+	for _, companyModel := range companyModels {
+		company, err := domainConverter(companyModel)
+		if err != nil {
+			u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, list)).Error(err.Error())
+			return domain.CompanyPage{}, err
+		}
+
+		page.Companies = append(page.Companies, company)
+	}
+
+	return page, nil
+}
+
+// listQueryBuilder returns the select statement, with `?` bind vars, and its arguments.
+// One extra row is requested so the caller can tell whether a next page exists.
+func listQueryBuilder(params domain.CompanyListParams) (string, []interface{}, error) {
+	sortColumn := params.SortBy.String()
+	if sortColumn == "" || params.Limit <= 0 {
+		return "", nil, postres.InvalidArgumentsForBuildingquery
+	}
+
+	conditions, args := filterConditions(params.Filter)
+
+	direction := "ASC"
+	comparison := ">"
+	if params.Descending {
+		direction = "DESC"
+		comparison = "<"
+	}
+
+	if params.Cursor != "" {
+		value, id, err := decodeCursor(params)
+		if err != nil {
+			return "", nil, err
+		}
+
+		conditions = append(conditions, fmt.Sprintf(`(%s, id) %s (?, ?)`, sortColumn, comparison))
+		args = append(args, value, id)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM xm_assessment.companies`, companyColumns)
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	query += fmt.Sprintf(` ORDER BY %s %s, id %s LIMIT ?`, sortColumn, direction, direction)
+	args = append(args, params.Limit+1)
+
+	return query, args, nil
+}
+
+// filterConditions returns the conditions, with `?` bind vars, selecting the companies
+// matching filter, and their arguments.
+func filterConditions(filter domain.CompanyFilter) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, `deleted_at IS NULL`)
+	}
+
+	if filter.Type != nil {
+		conditions = append(conditions, `type = ?`)
+		args = append(args, filter.Type.String())
+	}
+
+	if filter.IsRegistered != nil {
+		conditions = append(conditions, `is_registered = ?`)
+		args = append(args, *filter.IsRegistered)
+	}
+
+	if filter.MinEmployees != nil {
+		conditions = append(conditions, `employees_number >= ?`)
+		args = append(args, *filter.MinEmployees)
+	}
+
+	if filter.MaxEmployees != nil {
+		conditions = append(conditions, `employees_number <= ?`)
+		args = append(args, *filter.MaxEmployees)
+	}
+
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, `created_at >= ?`)
+		args = append(args, *filter.CreatedAfter)
+	}
+
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, `created_at < ?`)
+		args = append(args, *filter.CreatedBefore)
+	}
+
+	if filter.UpdatedAfter != nil {
+		conditions = append(conditions, `updated_at >= ?`)
+		args = append(args, *filter.UpdatedAfter)
+	}
+
+	if filter.UpdatedBefore != nil {
+		conditions = append(conditions, `updated_at < ?`)
+		args = append(args, *filter.UpdatedBefore)
+	}
+
+	return conditions, args
+}
+
+func encodeCursor(params domain.CompanyListParams, last model) string {
+	c := cursor{
+		Sort:       params.SortBy.String(),
+		Descending: params.Descending,
+		ID:         last.ID,
+	}
+
+	switch params.SortBy {
+	case domain.SortByName:
+		c.Value = last.Name
+	case domain.SortByCreatedAt:
+		c.Value = last.CreatedAt.Format(time.RFC3339Nano)
+	case domain.SortByUpdatedAt:
+		c.Value = last.UpdatedAt.Format(time.RFC3339Nano)
+	}
+
+	raw, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor returns the sort value and id the next page has to start after.
+func decodeCursor(params domain.CompanyListParams) (interface{}, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(params.Cursor)
+	if err != nil {
+		return nil, uuid.Nil, postres.InvalidCursor
+	}
+
+	c := cursor{}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, uuid.Nil, postres.InvalidCursor
+	}
+
+	if c.Sort != params.SortBy.String() || c.Descending != params.Descending {
+		return nil, uuid.Nil, postres.InvalidCursor
+	}
+
+	if params.SortBy == domain.SortByName {
+		return c.Value, c.ID, nil
+	}
+
+	value, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return nil, uuid.Nil, postres.InvalidCursor
+	}
+
+	return value, c.ID, nil
+}
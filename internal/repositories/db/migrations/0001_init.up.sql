@@ -0,0 +1,45 @@
+CREATE SCHEMA IF NOT EXISTS xm_assessment;
+
+CREATE TYPE xm_assessment.COMP_TYPE AS ENUM ('Corporations', 'NonProfit', 'Cooperative', 'Sole Proprietorship');
+
+CREATE TABLE xm_assessment.companies
+(
+    id               UUID        DEFAULT gen_random_UUID() PRIMARY KEY,
+    name             VARCHAR(15)               NOT NULL UNIQUE,
+    description      VARCHAR(3000),
+    employees_number INT                       NOT NULL,
+    is_registered    BOOLEAN                   NOT NULL,
+    type             xm_assessment.COMP_TYPE                 NOT NULL,
+    created_at       TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at       TIMESTAMPTZ               NOT NULL,
+    version          INT         DEFAULT 1     NOT NULL
+);
+
+CREATE INDEX companies_created_at_id_idx ON xm_assessment.companies (created_at, id);
+CREATE INDEX companies_updated_at_id_idx ON xm_assessment.companies (updated_at, id);
+
+CREATE TABLE xm_assessment.idempotency_keys
+(
+    key           VARCHAR(255) PRIMARY KEY,
+    request_hash  CHAR(64)                  NOT NULL,
+    status_code   INT,
+    headers       JSONB,
+    response_body BYTEA,
+    created_at    TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    expires_at    TIMESTAMPTZ               NOT NULL
+);
+
+CREATE TABLE xm_assessment.outbox
+(
+    id           BIGSERIAL PRIMARY KEY,
+    aggregate_id UUID                      NOT NULL,
+    event_type   VARCHAR(64)               NOT NULL,
+    headers      JSONB       DEFAULT '{}'  NOT NULL,
+    payload      BYTEA                     NOT NULL,
+    attempts     INT         DEFAULT 0     NOT NULL,
+    last_error   TEXT,
+    created_at   TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    sent_at      TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_unsent_idx ON xm_assessment.outbox (id) WHERE sent_at IS NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS xm_assessment.company_audit;
+DROP FUNCTION IF EXISTS xm_assessment.company_audit_append_only();
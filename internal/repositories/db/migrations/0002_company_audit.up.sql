@@ -0,0 +1,30 @@
+CREATE TABLE xm_assessment.company_audit
+(
+    id           BIGSERIAL PRIMARY KEY,
+    company_id   UUID                      NOT NULL,
+    company_name VARCHAR(15)               NOT NULL,
+    operation    VARCHAR(16)               NOT NULL,
+    actor        VARCHAR(255) DEFAULT ''   NOT NULL,
+    request_id   VARCHAR(128) DEFAULT ''   NOT NULL,
+    before       JSONB,
+    after        JSONB,
+    created_at   TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX company_audit_created_at_idx ON xm_assessment.company_audit (created_at, id);
+CREATE INDEX company_audit_company_id_idx ON xm_assessment.company_audit (company_id, id);
+CREATE INDEX company_audit_company_name_idx ON xm_assessment.company_audit (company_name);
+
+-- Audit entries are never changed nor removed once written.
+CREATE FUNCTION xm_assessment.company_audit_append_only() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'company_audit is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER company_audit_append_only
+    BEFORE UPDATE OR DELETE
+    ON xm_assessment.company_audit
+    FOR EACH ROW
+EXECUTE FUNCTION xm_assessment.company_audit_append_only();
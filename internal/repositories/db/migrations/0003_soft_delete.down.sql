@@ -0,0 +1,9 @@
+DELETE FROM xm_assessment.companies WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS xm_assessment.companies_deleted_at_idx;
+DROP INDEX IF EXISTS xm_assessment.companies_name_active_idx;
+
+ALTER TABLE xm_assessment.companies
+    ADD CONSTRAINT companies_name_key UNIQUE (name);
+ALTER TABLE xm_assessment.companies
+    DROP COLUMN deleted_at;
@@ -0,0 +1,9 @@
+ALTER TABLE xm_assessment.companies
+    ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- A name is only taken by a company that isn't deleted.
+ALTER TABLE xm_assessment.companies
+    DROP CONSTRAINT companies_name_key;
+CREATE UNIQUE INDEX companies_name_active_idx ON xm_assessment.companies (name) WHERE deleted_at IS NULL;
+
+CREATE INDEX companies_deleted_at_idx ON xm_assessment.companies (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS xm_assessment.company_revisions;
+DROP FUNCTION IF EXISTS xm_assessment.company_revisions_append_only();
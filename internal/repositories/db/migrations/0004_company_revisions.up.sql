@@ -0,0 +1,51 @@
+CREATE TABLE xm_assessment.company_revisions
+(
+    company_id       UUID                    NOT NULL,
+    version          INT                     NOT NULL,
+    operation        VARCHAR(16)             NOT NULL,
+    name             VARCHAR(15)             NOT NULL,
+    description      VARCHAR(3000),
+    employees_number INT                     NOT NULL,
+    is_registered    BOOLEAN                 NOT NULL,
+    type             xm_assessment.COMP_TYPE NOT NULL,
+    created_at       TIMESTAMPTZ             NOT NULL,
+    updated_at       TIMESTAMPTZ             NOT NULL,
+    deleted_at       TIMESTAMPTZ,
+    PRIMARY KEY (company_id, version)
+);
+
+CREATE INDEX company_revisions_name_idx ON xm_assessment.company_revisions (name);
+
+-- Revisions are never changed nor removed once written, not even when the company is purged.
+CREATE FUNCTION xm_assessment.company_revisions_append_only() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'company_revisions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER company_revisions_append_only
+    BEFORE UPDATE OR DELETE
+    ON xm_assessment.company_revisions
+    FOR EACH ROW
+EXECUTE FUNCTION xm_assessment.company_revisions_append_only();
+
+-- The companies existing so far start their history at their current state.
+INSERT INTO xm_assessment.company_revisions
+(company_id, version, operation, name, description, employees_number, is_registered, type, created_at, updated_at, deleted_at)
+SELECT id,
+       version,
+       CASE
+           WHEN deleted_at IS NOT NULL THEN 'delete'
+           WHEN version = 1 THEN 'create'
+           ELSE 'update'
+           END,
+       name,
+       description,
+       employees_number,
+       is_registered,
+       type,
+       created_at,
+       updated_at,
+       deleted_at
+FROM xm_assessment.companies;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS xm_assessment.companies_name_trgm_idx;
+DROP INDEX IF EXISTS xm_assessment.companies_search_vector_idx;
+
+ALTER TABLE xm_assessment.companies
+    DROP COLUMN IF EXISTS search_vector;
+
+-- pg_trgm is left installed, other schemas of the database may depend on it.
@@ -0,0 +1,13 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Generated, so that every insert and update keeps it in line with the name and description.
+ALTER TABLE xm_assessment.companies
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', name), 'A') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+        ) STORED;
+
+CREATE INDEX companies_search_vector_idx ON xm_assessment.companies USING GIN (search_vector);
+
+-- Serves the typo tolerant matching of names, through the similarity operator %.
+CREATE INDEX companies_name_trgm_idx ON xm_assessment.companies USING GIN (name gin_trgm_ops);
@@ -0,0 +1,3 @@
+ALTER TABLE xm_assessment.outbox
+    DROP COLUMN claimed_until,
+    DROP COLUMN claimed_by;
@@ -0,0 +1,5 @@
+-- A relay claims the messages it publishes for a while, so that publishing happens
+-- outside of any transaction and a crashed relay's claim eventually expires.
+ALTER TABLE xm_assessment.outbox
+    ADD COLUMN claimed_by    UUID,
+    ADD COLUMN claimed_until TIMESTAMPTZ;
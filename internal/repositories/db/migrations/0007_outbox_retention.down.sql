@@ -0,0 +1 @@
+DROP INDEX IF EXISTS xm_assessment.outbox_sent_at_idx;
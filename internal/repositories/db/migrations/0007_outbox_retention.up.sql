@@ -0,0 +1,2 @@
+-- Serves the cleanup of the messages sent longer than the retention ago.
+CREATE INDEX outbox_sent_at_idx ON xm_assessment.outbox (sent_at) WHERE sent_at IS NOT NULL;
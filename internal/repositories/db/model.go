@@ -2,27 +2,32 @@ package db
 
 import (
 	"company-crud/internal/domain"
+	"database/sql"
 	"github.com/google/uuid"
 	"time"
 )
 
 type model struct {
-	ID              uuid.UUID `db:"id"`
-	CurrentName     string    `db:"current_name,omitempty"`
-	Name            string    `db:"name,omitempty"`
-	Description     string    `db:"description,omitempty"`
-	EmployeesNumber int       `db:"employees_number,omitempty"`
-	IsRegistered    bool      `db:"is_registered,omitempty"`
-	Type            string    `db:"type,omitempty"`
-	CreatedAt       time.Time `db:"created_at,omitempty"`
-	UpdatedAt       time.Time `db:"updated_at"`
+	ID              uuid.UUID  `db:"id"`
+	CurrentName     string     `db:"current_name,omitempty"`
+	Name            string     `db:"name,omitempty"`
+	Description     string     `db:"description,omitempty"`
+	EmployeesNumber int        `db:"employees_number,omitempty"`
+	IsRegistered    bool       `db:"is_registered,omitempty"`
+	Type            string     `db:"type,omitempty"`
+	CreatedAt       time.Time  `db:"created_at,omitempty"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+	Version         int        `db:"version"`
+	DeletedAt       *time.Time `db:"deleted_at"`
+	ExpectedID      uuid.UUID  `db:"expected_id,omitempty"`
+	ExpectedVersion int        `db:"expected_version,omitempty"`
 }
 
 func modelConverter(d domain.Company) model {
+	// UpdatedAt is left to the database, whose clock orders the revisions.
 	companyModel := model{
-		ID:        d.ID,
-		Name:      d.Name,
-		UpdatedAt: time.Now(),
+		ID:   d.ID,
+		Name: d.Name,
 	}
 
 	if d.Type != nil {
@@ -43,3 +48,66 @@ func modelConverter(d domain.Company) model {
 
 	return companyModel
 }
+
+func domainConverter(m model) (domain.Company, error) {
+	companyType, err := domain.GetCompTypeFromString(m.Type)
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return domain.Company{
+		ID:              m.ID,
+		Name:            m.Name,
+		Description:     &m.Description,
+		EmployeesNumber: &m.EmployeesNumber,
+		IsRegistered:    &m.IsRegistered,
+		Type:            &companyType,
+		UpdatedAt:       m.UpdatedAt,
+		CreatedAt:       m.CreatedAt,
+		Version:         m.Version,
+		DeletedAt:       m.DeletedAt,
+	}, nil
+}
+
+type idempotencyModel struct {
+	Key          string        `db:"key"`
+	RequestHash  string        `db:"request_hash"`
+	StatusCode   sql.NullInt64 `db:"status_code"`
+	Headers      []byte        `db:"headers"`
+	ResponseBody []byte        `db:"response_body"`
+	CreatedAt    time.Time     `db:"created_at"`
+}
+
+type outboxModel struct {
+	ID          int64     `db:"id"`
+	AggregateID uuid.UUID `db:"aggregate_id"`
+	EventType   string    `db:"event_type"`
+	Headers     []byte    `db:"headers"`
+	Payload     []byte    `db:"payload"`
+	Attempts    int       `db:"attempts"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+type revisionModel struct {
+	model
+	Operation string `db:"operation"`
+}
+
+type searchModel struct {
+	model
+	Rank          float64 `db:"rank"`
+	NameHighlight string  `db:"name_highlight"`
+	Snippet       string  `db:"snippet"`
+}
+
+type auditModel struct {
+	ID          int64     `db:"id"`
+	CompanyID   uuid.UUID `db:"company_id"`
+	CompanyName string    `db:"company_name"`
+	Operation   string    `db:"operation"`
+	Actor       string    `db:"actor"`
+	RequestID   string    `db:"request_id"`
+	Before      []byte    `db:"before"`
+	After       []byte    `db:"after"`
+	CreatedAt   time.Time `db:"created_at"`
+}
@@ -0,0 +1,234 @@
+package db
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/internal/events"
+	"company-crud/pkg/logger"
+	"company-crud/pkg/postres"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"time"
+)
+
+const outboxErrorSection = "outboxDB"
+const relay = "relay"
+const cleanup = "cleanup"
+
+// outboxLockID is the advisory lock serializing the claims of relays across instances,
+// which keeps messages published in the order they were written.
+const outboxLockID = 7_000_001
+
+type Outbox struct {
+	db     *postres.Postgres
+	logger *logger.Logger
+}
+
+func NewOutbox(db *postres.Postgres, log *logger.Logger) *Outbox {
+	return &Outbox{
+		db:     db,
+		logger: log,
+	}
+}
+
+// insertEvent records the event about a company change within the transaction of the
+// change. before is the state prior to an update.
+func insertEvent(ctx context.Context, tx *sqlx.Tx, eventType events.Type, current model, before *model) error {
+	company, err := domainConverter(current)
+	if err != nil {
+		return err
+	}
+
+	var previous *domain.Company
+	if before != nil {
+		b, err := domainConverter(*before)
+		if err != nil {
+			return err
+		}
+		previous = &b
+	}
+
+	event := events.New(eventType, company, previous)
+	payload, err := event.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return insertOutbox(ctx, tx, company.ID, event.Type, event.Headers(), payload)
+}
+
+// insertBatchEvent records the event summing up the changes of a batch within its
+// transaction. It is keyed by the batch id, so it isn't ordered with the events of the
+// companies it changed.
+func insertBatchEvent(ctx context.Context, tx *sqlx.Tx, changes []events.Change) error {
+	batchID := uuid.New()
+	event := events.NewBatch(batchID, changes)
+	payload, err := event.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return insertOutbox(ctx, tx, batchID, event.Type, event.Headers(), payload)
+}
+
+func insertOutbox(ctx context.Context, tx *sqlx.Tx, aggregateID uuid.UUID, eventType events.Type, eventHeaders map[string]string, payload []byte) error {
+	// The trace context of the change travels with the event up to the consumers.
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(eventHeaders))
+
+	headers, err := json.Marshal(eventHeaders)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO xm_assessment.outbox (aggregate_id, event_type, headers, payload) VALUES ($1, $2, $3, $4)`,
+		aggregateID, eventType, string(headers), payload)
+
+	return err
+}
+
+// Relay claims the oldest unsent messages in a short transaction, publishes them outside
+// of it and records the outcome in a second one, so that no transaction nor the relay lock
+// is held while waiting for the broker.
+func (o *Outbox) Relay(ctx context.Context, limit int, lease time.Duration, publish func(context.Context, domain.OutboxMessage) error) (int, error) {
+	claim := uuid.New()
+
+	outboxModels, err := o.claim(ctx, claim, limit, lease)
+	if err != nil {
+		o.logger.Named(fmt.Sprintf("%s:%s", outboxErrorSection, relay)).Error(err.Error())
+		return 0, postres.ContextErr(ctx, err)
+	}
+
+	if len(outboxModels) == 0 {
+		return 0, nil
+	}
+
+	// Publishing must not outlive the claim, otherwise another relay could publish the
+	// messages after these ones.
+	publishCtx, cancel := context.WithTimeout(ctx, lease)
+	defer cancel()
+
+	sent := make([]int64, 0, len(outboxModels))
+	var failed int64
+	var publishErr error
+
+	for _, m := range outboxModels {
+		headers := map[string]string{}
+		if publishErr = json.Unmarshal(m.Headers, &headers); publishErr == nil {
+			publishErr = publish(publishCtx, domain.OutboxMessage{
+				ID:          m.ID,
+				AggregateID: m.AggregateID,
+				EventType:   m.EventType,
+				Headers:     headers,
+				Payload:     m.Payload,
+				Attempts:    m.Attempts,
+				CreatedAt:   m.CreatedAt,
+			})
+		}
+
+		if publishErr != nil {
+			failed = m.ID
+			break
+		}
+		sent = append(sent, m.ID)
+	}
+
+	// The outcome is recorded even when ctx is done, what was published must not be sent again.
+	if err := o.settle(context.WithoutCancel(ctx), claim, sent, failed, publishErr); err != nil {
+		o.logger.Named(fmt.Sprintf("%s:%s", outboxErrorSection, relay)).Error(err.Error())
+		return 0, err
+	}
+
+	return len(sent), publishErr
+}
+
+// Cleanup deletes up to limit messages sent before sentBefore and returns how many.
+func (o *Outbox) Cleanup(ctx context.Context, sentBefore time.Time, limit int) (int, error) {
+	result, err := o.db.ExecContext(ctx, `DELETE FROM xm_assessment.outbox WHERE id IN (
+			SELECT id FROM xm_assessment.outbox WHERE sent_at < $1 LIMIT $2
+		 )`, sentBefore, limit)
+	if err != nil {
+		o.logger.Named(fmt.Sprintf("%s:%s", outboxErrorSection, cleanup)).Error(err.Error())
+		return 0, postres.ContextErr(ctx, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+// claim marks the oldest unsent messages, up to limit, as being published by claim for
+// lease. Nothing is claimed while another relay holds an unexpired claim, which keeps
+// messages published in order.
+func (o *Outbox) claim(ctx context.Context, claim uuid.UUID, limit int, lease time.Duration) ([]outboxModel, error) {
+	var outboxModels []outboxModel
+
+	err := o.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		var locked bool
+		if err := tx.GetContext(ctx, &locked, `SELECT pg_try_advisory_xact_lock($1)`, outboxLockID); err != nil {
+			return err
+		}
+
+		// Another instance is claiming.
+		if !locked {
+			return nil
+		}
+
+		var claimed bool
+		err := tx.GetContext(ctx, &claimed, `SELECT EXISTS (SELECT 1 FROM xm_assessment.outbox
+			  WHERE sent_at IS NULL AND claimed_until > NOW())`)
+		if err != nil || claimed {
+			return err
+		}
+
+		err = tx.SelectContext(ctx, &outboxModels, `SELECT id, aggregate_id, event_type, headers, payload, attempts, created_at
+			  FROM xm_assessment.outbox WHERE sent_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`, limit)
+		if err != nil || len(outboxModels) == 0 {
+			return err
+		}
+
+		ids := make([]int64, len(outboxModels))
+		for i, m := range outboxModels {
+			ids[i] = m.ID
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE xm_assessment.outbox SET claimed_by = $1, claimed_until = NOW() + make_interval(secs => $2)
+			  WHERE id = ANY($3)`, claim, lease.Seconds(), pq.Array(ids))
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return outboxModels, nil
+}
+
+// settle marks the sent messages, records publishErr on the failed one and releases what
+// is left of claim.
+func (o *Outbox) settle(ctx context.Context, claim uuid.UUID, sent []int64, failed int64, publishErr error) error {
+	return o.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE xm_assessment.outbox
+			  SET attempts = attempts + 1, last_error = NULL, sent_at = NOW(), claimed_by = NULL, claimed_until = NULL
+			  WHERE id = ANY($1)`, pq.Array(sent))
+		if err != nil {
+			return err
+		}
+
+		if publishErr != nil {
+			_, err = tx.ExecContext(ctx, `UPDATE xm_assessment.outbox SET attempts = attempts + 1, last_error = $2
+				  WHERE id = $1 AND claimed_by = $3`, failed, publishErr.Error(), claim)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE xm_assessment.outbox SET claimed_by = NULL, claimed_until = NULL
+			  WHERE claimed_by = $1`, claim)
+
+		return err
+	})
+}
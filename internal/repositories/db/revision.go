@@ -0,0 +1,210 @@
+package db
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/pkg/postres"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+const revisionColumns = `company_id AS id, name, description, employees_number, is_registered, type, created_at, updated_at, version, deleted_at, operation`
+
+// insertRevision records the state a change left the company in, within the transaction
+// of the change.
+func insertRevision(ctx context.Context, tx *sqlx.Tx, operation domain.AuditOperation, m model) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO xm_assessment.company_revisions
+		 (company_id, version, operation, name, description, employees_number, is_registered, type, created_at, updated_at, deleted_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		m.ID, m.Version, string(operation), m.Name, m.Description, m.EmployeesNumber, m.IsRegistered, m.Type,
+		m.CreatedAt, m.UpdatedAt, m.DeletedAt)
+
+	return err
+}
+
+// GetByNameAsOf looks for the company that had the name, and wasn't deleted, in the last
+// revision of every company made before asOf.
+func (u *Company) GetByNameAsOf(ctx context.Context, name string, asOf time.Time) (domain.Company, error) {
+	m := revisionModel{}
+	err := u.db.GetContext(ctx, &m,
+		`SELECT `+revisionColumns+` FROM (
+			SELECT DISTINCT ON (company_id) * FROM xm_assessment.company_revisions
+			WHERE company_id IN (SELECT company_id FROM xm_assessment.company_revisions WHERE name = $1)
+			  AND updated_at <= $2
+			ORDER BY company_id, version DESC
+		 ) latest
+		 WHERE name = $1 AND deleted_at IS NULL
+		 ORDER BY updated_at DESC LIMIT 1`,
+		name, asOf)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, getAsOf)).Error(err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Company{}, postres.NoRowsErr
+		}
+		return domain.Company{}, postres.ContextErr(ctx, err)
+	}
+
+	revision, err := revisionConverter(m)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, getAsOf)).Error(err.Error())
+		return domain.Company{}, err
+	}
+
+	return revision.Company, nil
+}
+
+// ListRevisions pages through the revisions of the company currently having the name.
+func (u *Company) ListRevisions(ctx context.Context, name string, params domain.RevisionListParams) (domain.RevisionPage, error) {
+	if params.Limit <= 0 {
+		return domain.RevisionPage{}, postres.InvalidArgumentsForBuildingquery
+	}
+
+	company, err := u.GetByName(ctx, name)
+	if err != nil {
+		return domain.RevisionPage{}, err
+	}
+
+	query := `SELECT ` + revisionColumns + ` FROM xm_assessment.company_revisions WHERE company_id = ?`
+	args := []interface{}{company.ID}
+	if params.Cursor != "" {
+		version, err := decodeSeqCursor(params.Cursor)
+		if err != nil {
+			return domain.RevisionPage{}, err
+		}
+
+		query += ` AND version < ?`
+		args = append(args, version)
+	}
+	query += ` ORDER BY version DESC LIMIT ?`
+	args = append(args, params.Limit+1)
+
+	var revisionModels []revisionModel
+	err = u.db.SelectContext(ctx, &revisionModels, u.db.Rebind(query), args...)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, listRevisions)).Error(err.Error())
+		return domain.RevisionPage{}, postres.ContextErr(ctx, err)
+	}
+
+	page := domain.RevisionPage{}
+	if len(revisionModels) > params.Limit {
+		revisionModels = revisionModels[:params.Limit]
+		page.NextCursor = encodeSeqCursor(int64(revisionModels[len(revisionModels)-1].Version))
+	}
+
+	page.Revisions = make([]domain.Revision, 0, len(revisionModels))
+	for _, m := range revisionModels {
+		revision, err := revisionConverter(m)
+		if err != nil {
+			u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, listRevisions)).Error(err.Error())
+			return domain.RevisionPage{}, err
+		}
+		page.Revisions = append(page.Revisions, revision)
+	}
+
+	return page, nil
+}
+
+// GetRevision returns a revision of the company currently having the name.
+func (u *Company) GetRevision(ctx context.Context, name string, version int) (domain.Revision, error) {
+	m := revisionModel{}
+	err := u.db.GetContext(ctx, &m,
+		`SELECT `+revisionColumns+` FROM xm_assessment.company_revisions
+		 WHERE company_id = (SELECT id FROM xm_assessment.companies WHERE name = $1 AND deleted_at IS NULL)
+		   AND version = $2`,
+		name, version)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, getRevision)).Error(err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Revision{}, postres.NoRowsErr
+		}
+		return domain.Revision{}, postres.ContextErr(ctx, err)
+	}
+
+	revision, err := revisionConverter(m)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, getRevision)).Error(err.Error())
+		return domain.Revision{}, err
+	}
+
+	return revision, nil
+}
+
+// Revert copies the fields of a revision over the company currently having the name, which
+// makes a new revision rather than rewriting the history.
+func (u *Company) Revert(ctx context.Context, name string, version int, precondition *domain.Precondition) (domain.Company, error) {
+	selectQuery := `SELECT ` + companyColumns + ` FROM xm_assessment.companies WHERE name=$1 AND deleted_at IS NULL`
+	args := []interface{}{name}
+	if precondition != nil {
+		selectQuery += ` AND id=$2 AND version=$3`
+		args = append(args, precondition.ID, precondition.Version)
+	}
+	selectQuery += ` FOR UPDATE`
+
+	after := model{}
+	err := u.db.InTx(ctx, func(tx *sqlx.Tx) error {
+		before := model{}
+		if err := tx.GetContext(ctx, &before, selectQuery, args...); err != nil {
+			return err
+		}
+
+		target := revisionModel{}
+		err := tx.GetContext(ctx, &target,
+			`SELECT `+revisionColumns+` FROM xm_assessment.company_revisions WHERE company_id=$1 AND version=$2`,
+			before.ID, version)
+		if err != nil {
+			// A missing revision isn't a failed precondition.
+			if errors.Is(err, sql.ErrNoRows) {
+				return postres.NoRowsErr
+			}
+			return err
+		}
+
+		err = tx.GetContext(ctx, &after,
+			`UPDATE xm_assessment.companies
+			 SET name=$2, description=$3, employees_number=$4, is_registered=$5, type=$6, updated_at=NOW(), version=version+1
+			 WHERE id=$1 RETURNING `+companyColumns,
+			before.ID, target.Name, target.Description, target.EmployeesNumber, target.IsRegistered, target.Type)
+		if err != nil {
+			return err
+		}
+
+		return recordChange(ctx, tx, domain.AuditRevert, &before, &after)
+	})
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, revert)).Error(err.Error())
+		if errors.Is(err, postres.NoRowsErr) {
+			return domain.Company{}, postres.NoRowsErr
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Company{}, noRowsErr(precondition)
+		}
+		if isDuplicateKey(err) {
+			return domain.Company{}, postres.DuplicateKey
+		}
+		return domain.Company{}, postres.ContextErr(ctx, err)
+	}
+
+	company, err := domainConverter(after)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, revert)).Error(err.Error())
+		return domain.Company{}, err
+	}
+
+	return company, nil
+}
+
+func revisionConverter(m revisionModel) (domain.Revision, error) {
+	company, err := domainConverter(m.model)
+	if err != nil {
+		return domain.Revision{}, err
+	}
+
+	return domain.Revision{
+		Operation: domain.AuditOperation(m.Operation),
+		Company:   company,
+	}, nil
+}
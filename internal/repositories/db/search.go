@@ -0,0 +1,159 @@
+package db
+
+import (
+	"company-crud/internal/domain"
+	"company-crud/pkg/postres"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"html"
+	"strconv"
+	"strings"
+)
+
+const (
+	searchCursorSort = "rank"
+	// highlightStart and highlightStop delimit the matched words in the headlines. They
+	// aren't HTML, so that the headlines can be escaped before they become <mark> tags,
+	// and are removed from the text headlined.
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+	// searchHighlight wraps the matched words of the name and the description snippet.
+	searchHighlight = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `"` +
+		`, MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=" … "`
+)
+
+// highlightTags turns the delimiters of the matched words into <mark> tags.
+var highlightTags = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// Search matches the text against the search_vector of the companies, and their names by
+// trigram similarity so that typos still find them. Results are ranked by the sum of both.
+func (u *Company) Search(ctx context.Context, params domain.CompanySearchParams) (domain.CompanySearchPage, error) {
+	query, args, err := searchQueryBuilder(params)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, search)).Error(err.Error())
+		return domain.CompanySearchPage{}, err
+	}
+
+	var searchModels []searchModel
+	err = u.db.SelectContext(ctx, &searchModels, u.db.Rebind(query), args...)
+	if err != nil {
+		u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, search)).Error(err.Error())
+		return domain.CompanySearchPage{}, postres.ContextErr(ctx, err)
+	}
+
+	page := domain.CompanySearchPage{}
+	if len(searchModels) > params.Limit {
+		searchModels = searchModels[:params.Limit]
+		page.NextCursor = encodeSearchCursor(searchModels[len(searchModels)-1])
+	}
+
+	page.Results = make([]domain.CompanySearchResult, 0, len(searchModels))
+	for _, m := range searchModels {
+		company, err := domainConverter(m.model)
+		if err != nil {
+			u.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, search)).Error(err.Error())
+			return domain.CompanySearchPage{}, err
+		}
+
+		page.Results = append(page.Results, domain.CompanySearchResult{
+			Company:       company,
+			Rank:          m.Rank,
+			NameHighlight: highlightHTML(m.NameHighlight),
+			Snippet:       highlightHTML(m.Snippet),
+		})
+	}
+
+	return page, nil
+}
+
+// highlightHTML escapes a headline and wraps its matched words in <mark> tags.
+func highlightHTML(headline string) string {
+	return highlightTags.Replace(html.EscapeString(headline))
+}
+
+// searchQueryBuilder returns the select statement, with `?` bind vars, and its arguments.
+// One extra row is requested so the caller can tell whether a next page exists.
+func searchQueryBuilder(params domain.CompanySearchParams) (string, []interface{}, error) {
+	if strings.TrimSpace(params.Text) == "" || params.Limit <= 0 {
+		return "", nil, postres.InvalidArgumentsForBuildingquery
+	}
+
+	conditions := []string{
+		`deleted_at IS NULL`,
+		`(search_vector @@ text_query OR name % ?)`,
+	}
+	args := []interface{}{params.Text, params.Text, params.Text}
+
+	if params.Type != nil {
+		conditions = append(conditions, `type = ?`)
+		args = append(args, params.Type.String())
+	}
+
+	if params.IsRegistered != nil {
+		conditions = append(conditions, `is_registered = ?`)
+		args = append(args, *params.IsRegistered)
+	}
+
+	// The rank is computed once by the inner query, the headlines only for the page.
+	query := fmt.Sprintf(`SELECT %s, rank,
+			ts_headline('english', translate(name, E'\x02\x03', ''), text_query, '%s') AS name_highlight,
+			ts_headline('english', translate(COALESCE(description, ''), E'\x02\x03', ''), text_query, '%s') AS snippet
+		FROM (
+			SELECT %s, text_query,
+				(ts_rank_cd(search_vector, text_query) + similarity(name, ?))::FLOAT8 AS rank
+			FROM xm_assessment.companies, websearch_to_tsquery('english', ?) AS text_query
+			WHERE %s
+		) AS matches`,
+		companyColumns, searchHighlight, searchHighlight, companyColumns, strings.Join(conditions, ` AND `))
+
+	if params.Cursor != "" {
+		rank, id, err := decodeSearchCursor(params.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+
+		query += ` WHERE (rank, id) < (?, ?)`
+		args = append(args, rank, id)
+	}
+
+	query += ` ORDER BY rank DESC, id DESC LIMIT ?`
+	args = append(args, params.Limit+1)
+
+	return query, args, nil
+}
+
+func encodeSearchCursor(last searchModel) string {
+	c := cursor{
+		Sort:       searchCursorSort,
+		Descending: true,
+		Value:      strconv.FormatFloat(last.Rank, 'g', -1, 64),
+		ID:         last.ID,
+	}
+
+	raw, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeSearchCursor returns the rank and id the next page has to start after.
+func decodeSearchCursor(encoded string) (float64, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, uuid.Nil, postres.InvalidCursor
+	}
+
+	c := cursor{}
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != searchCursorSort {
+		return 0, uuid.Nil, postres.InvalidCursor
+	}
+
+	rank, err := strconv.ParseFloat(c.Value, 64)
+	if err != nil {
+		return 0, uuid.Nil, postres.InvalidCursor
+	}
+
+	return rank, c.ID, nil
+}
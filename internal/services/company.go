@@ -3,88 +3,295 @@ package services
 import (
 	"company-crud/internal/domain"
 	"company-crud/pkg/logger"
-	"company-crud/pkg/producer"
+	"context"
 	"fmt"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"time"
 )
 
 const errorSection = "companyService"
+const tracerName = "company-crud/internal/services"
 const (
 	create  = "create"
 	deleteM = "delete"
 	get     = "get"
 	patch   = "patch"
+	list    = "list"
+	audit   = "audit"
+	restore = "restore"
+	revert  = "revert"
+
+	applyBatch = "batch"
+	export     = "export"
+	search     = "search"
 )
 
+// Company events are written to the outbox by companyDB, in the same transaction as
+// the change itself, and published from there by the app's relay.
 type Company struct {
-	producer  *producer.KafkaProducer
 	companyDB domain.CompanyDB
 	logger    *logger.Logger
+	tracer    trace.Tracer
 }
 
-func New(log *logger.Logger, prod *producer.KafkaProducer, compDB domain.CompanyDB) *Company {
+func New(log *logger.Logger, compDB domain.CompanyDB) *Company {
 	return &Company{
-		producer:  prod,
 		companyDB: compDB,
 		logger:    log,
+		tracer:    otel.Tracer(tracerName),
 	}
 }
 
-func (c *Company) Create(company domain.Company) (uuid.UUID, error) {
-	id, err := c.companyDB.Insert(company)
+// endSpan ends a span, marking it failed when err is set.
+func endSpan(span trace.Span, err error) {
 	if err != nil {
-		return uuid.UUID{}, err
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
+	span.End()
+}
 
-	err = c.producer.ProduceEvent([]byte(fmt.Sprintf("New company created, with ID: %s", id.String())))
+func (c *Company) Create(ctx context.Context, company domain.Company) (uuid.UUID, error) {
+	ctx, span := c.tracer.Start(ctx, "companyService.Create")
+	id, err := c.companyDB.Insert(ctx, company)
+	endSpan(span, err)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
 
-	c.logger.Named(fmt.Sprintf("%s:%s", errorSection, create)).Info("Company entry and event created")
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, create)).Info("Company entry and event created")
 
 	return id, nil
 }
 
-func (c *Company) Delete(companyName string) error {
-	err := c.companyDB.DeleteByName(companyName)
+func (c *Company) Delete(ctx context.Context, companyName string, precondition *domain.Precondition) error {
+	ctx, span := c.tracer.Start(ctx, "companyService.Delete")
+	err := c.companyDB.DeleteByName(ctx, companyName, precondition)
+	endSpan(span, err)
 	if err != nil {
 		return err
 	}
 
-	err = c.producer.ProduceEvent([]byte(fmt.Sprintf("New company created, with name: %s", companyName)))
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, deleteM)).Info("Company entry deleted")
+
+	return nil
+}
+
+func (c *Company) DeleteByID(ctx context.Context, id uuid.UUID, precondition *domain.Precondition) error {
+	ctx, span := c.tracer.Start(ctx, "companyService.DeleteByID")
+	err := c.companyDB.DeleteByID(ctx, id, precondition)
+	endSpan(span, err)
 	if err != nil {
 		return err
 	}
 
-	c.logger.Named(fmt.Sprintf("%s:%s", errorSection, deleteM)).Info("Company entry deleted")
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, deleteM)).Info("Company entry deleted")
 
 	return nil
 }
 
-func (c *Company) Get(companyName string) (domain.Company, error) {
-	company, err := c.companyDB.GetByName(companyName)
+func (c *Company) Get(ctx context.Context, companyName string, includeDeleted bool) (domain.Company, error) {
+	ctx, span := c.tracer.Start(ctx, "companyService.Get")
+	var company domain.Company
+	var err error
+	if includeDeleted {
+		company, err = c.companyDB.GetByNameIncludingDeleted(ctx, companyName)
+	} else {
+		company, err = c.companyDB.GetByName(ctx, companyName)
+	}
+	endSpan(span, err)
 	if err != nil {
 		return domain.Company{}, err
 	}
 
-	c.logger.Named(fmt.Sprintf("%s:%s", errorSection, get)).Info("Company info retrieved")
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, get)).Info("Company info retrieved")
 
 	return company, nil
 }
 
-func (c *Company) Patch(company domain.Company, currentName string) error {
-	err := c.companyDB.PatchByName(company, currentName)
+func (c *Company) GetByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (domain.Company, error) {
+	ctx, span := c.tracer.Start(ctx, "companyService.GetByID")
+	var company domain.Company
+	var err error
+	if includeDeleted {
+		company, err = c.companyDB.GetByIDIncludingDeleted(ctx, id)
+	} else {
+		company, err = c.companyDB.GetByID(ctx, id)
+	}
+	endSpan(span, err)
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, get)).Info("Company info retrieved")
+
+	return company, nil
+}
+
+func (c *Company) List(ctx context.Context, params domain.CompanyListParams) (domain.CompanyPage, error) {
+	ctx, span := c.tracer.Start(ctx, "companyService.List")
+	page, err := c.companyDB.List(ctx, params)
+	endSpan(span, err)
+	if err != nil {
+		return domain.CompanyPage{}, err
+	}
+
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, list)).Info("Company list retrieved")
+
+	return page, nil
+}
+
+func (c *Company) ListAudit(ctx context.Context, params domain.AuditListParams) (domain.AuditPage, error) {
+	ctx, span := c.tracer.Start(ctx, "companyService.ListAudit")
+	page, err := c.companyDB.ListAudit(ctx, params)
+	endSpan(span, err)
+	if err != nil {
+		return domain.AuditPage{}, err
+	}
+
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, audit)).Info("Company audit retrieved")
+
+	return page, nil
+}
+
+func (c *Company) Patch(ctx context.Context, company domain.Company, currentName string, precondition *domain.Precondition) error {
+	ctx, span := c.tracer.Start(ctx, "companyService.Patch")
+	err := c.companyDB.PatchByName(ctx, company, currentName, precondition)
+	endSpan(span, err)
 	if err != nil {
 		return err
 	}
 
-	err = c.producer.ProduceEvent([]byte(fmt.Sprintf("Company entry patched, with ID: %s", company.ID.String())))
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, patch)).Info("Company info retrieved")
+
+	return nil
+}
+
+func (c *Company) PatchByID(ctx context.Context, company domain.Company, id uuid.UUID, precondition *domain.Precondition) error {
+	ctx, span := c.tracer.Start(ctx, "companyService.PatchByID")
+	err := c.companyDB.PatchByID(ctx, company, id, precondition)
+	endSpan(span, err)
 	if err != nil {
 		return err
 	}
 
-	c.logger.Named(fmt.Sprintf("%s:%s", errorSection, patch)).Info("Company info retrieved")
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, patch)).Info("Company info patched")
 
 	return nil
 }
+
+func (c *Company) Restore(ctx context.Context, companyName string) error {
+	ctx, span := c.tracer.Start(ctx, "companyService.Restore")
+	err := c.companyDB.Restore(ctx, companyName)
+	endSpan(span, err)
+	if err != nil {
+		return err
+	}
+
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, restore)).Info("Company entry restored")
+
+	return nil
+}
+
+func (c *Company) GetAsOf(ctx context.Context, companyName string, asOf time.Time) (domain.Company, error) {
+	ctx, span := c.tracer.Start(ctx, "companyService.GetAsOf")
+	company, err := c.companyDB.GetByNameAsOf(ctx, companyName, asOf)
+	endSpan(span, err)
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, get)).Info("Company info retrieved")
+
+	return company, nil
+}
+
+func (c *Company) ListRevisions(ctx context.Context, companyName string, params domain.RevisionListParams) (domain.RevisionPage, error) {
+	ctx, span := c.tracer.Start(ctx, "companyService.ListRevisions")
+	page, err := c.companyDB.ListRevisions(ctx, companyName, params)
+	endSpan(span, err)
+	if err != nil {
+		return domain.RevisionPage{}, err
+	}
+
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, list)).Info("Company revisions retrieved")
+
+	return page, nil
+}
+
+func (c *Company) GetRevision(ctx context.Context, companyName string, version int) (domain.Revision, error) {
+	ctx, span := c.tracer.Start(ctx, "companyService.GetRevision")
+	revision, err := c.companyDB.GetRevision(ctx, companyName, version)
+	endSpan(span, err)
+	if err != nil {
+		return domain.Revision{}, err
+	}
+
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, get)).Info("Company revision retrieved")
+
+	return revision, nil
+}
+
+func (c *Company) Revert(ctx context.Context, companyName string, version int, precondition *domain.Precondition) (domain.Company, error) {
+	ctx, span := c.tracer.Start(ctx, "companyService.Revert")
+	company, err := c.companyDB.Revert(ctx, companyName, version, precondition)
+	endSpan(span, err)
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, revert)).Info("Company entry reverted")
+
+	return company, nil
+}
+
+func (c *Company) Batch(ctx context.Context, batch domain.Batch) ([]domain.BatchResult, error) {
+	ctx, span := c.tracer.Start(ctx, "companyService.Batch")
+	results, err := c.companyDB.Batch(ctx, batch)
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	if batch.DryRun {
+		c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, applyBatch)).Info(fmt.Sprintf("Company batch of %d items checked", len(results)))
+		return results, nil
+	}
+
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, applyBatch)).Info(fmt.Sprintf("Company batch of %d items applied", len(results)))
+
+	return results, nil
+}
+
+func (c *Company) Export(ctx context.Context, params domain.CompanyExportParams, fn func(domain.Company) error) error {
+	ctx, span := c.tracer.Start(ctx, "companyService.Export")
+	exported := 0
+	err := c.companyDB.Export(ctx, params, func(company domain.Company) error {
+		exported++
+		return fn(company)
+	})
+	endSpan(span, err)
+	if err != nil {
+		return err
+	}
+
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, export)).Info(fmt.Sprintf("Company export of %d companies streamed", exported))
+
+	return nil
+}
+
+func (c *Company) Search(ctx context.Context, params domain.CompanySearchParams) (domain.CompanySearchPage, error) {
+	ctx, span := c.tracer.Start(ctx, "companyService.Search")
+	page, err := c.companyDB.Search(ctx, params)
+	endSpan(span, err)
+	if err != nil {
+		return domain.CompanySearchPage{}, err
+	}
+
+	c.logger.For(ctx).Named(fmt.Sprintf("%s:%s", errorSection, search)).Info("Company search done")
+
+	return page, nil
+}
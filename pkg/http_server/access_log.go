@@ -0,0 +1,130 @@
+package http_server
+
+import (
+	"company-crud/pkg/logger"
+	"company-crud/pkg/requestid"
+	"context"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const (
+	accessLogSection   = "access"
+	maxRequestIDLength = 128
+)
+
+// requestInfo is what the access log learns about a request as it is served.
+type requestInfo struct {
+	// route is the template of the route matched, or "unknown" when none was.
+	route   string
+	subject string
+}
+
+type requestInfoKey struct{}
+
+// routeOf returns the template of the route that served the request with ctx, once served.
+func routeOf(ctx context.Context) string {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		return info.route
+	}
+
+	return "unknown"
+}
+
+// recordRoute runs once the router matched a route, to let the access log and the metrics,
+// which wrap the router so that unmatched requests are seen too, know about it.
+func recordRoute(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo)
+		if current := mux.CurrentRoute(r); ok && current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				info.route = template
+			}
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// SetSubject records who made the request served with ctx, for the access log.
+func SetSubject(ctx context.Context, subject string) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.subject = subject
+	}
+}
+
+// validRequestID accepts ids of printable ASCII, so that a client can't forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// quietRoute tells the routes polled by infrastructure, which are logged at debug level only.
+func quietRoute(route string) bool {
+	switch route {
+	case "/healthz", "/readyz", "/metrics":
+		return true
+	}
+
+	return false
+}
+
+// accessLog tags every request with the X-Request-ID it came with, or a generated one,
+// echoes it in the response and serves the request with a logger carrying it. Once
+// answered, the request is logged in a single line. It wraps the whole router, so that
+// requests matching no route are logged too.
+func accessLog(log *logger.Logger) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = uuid.NewString()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			info := &requestInfo{route: "unknown"}
+			requestLog := &logger.Logger{Logger: log.With(zap.String("request_id", id))}
+
+			ctx := requestid.NewContext(r.Context(), id)
+			ctx = context.WithValue(ctx, requestInfoKey{}, info)
+			ctx = logger.NewContext(ctx, requestLog)
+
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			h.ServeHTTP(rec, r.WithContext(ctx))
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			route := info.route
+			write := requestLog.Named(accessLogSection).Info
+			if quietRoute(route) {
+				write = requestLog.Named(accessLogSection).Debug
+			}
+			write("request served",
+				zap.String("method", r.Method),
+				zap.String("route", route),
+				zap.Int("status", rec.status),
+				zap.Int("bytes", rec.bytes),
+				zap.Duration("latency", time.Since(start)),
+				zap.String("remote_addr", r.RemoteAddr),
+				zap.String("subject", info.subject),
+			)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package http_server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout bounds every dependency check made by /readyz.
+const healthCheckTimeout = 2 * time.Second
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+	statusDraining    = "draining"
+)
+
+// Check reports whether a dependency is usable.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type Health struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// health serves /healthz, answering as long as the process does, and /readyz, which
+// answers 503 while a dependency check fails or once the server started draining.
+type health struct {
+	mu       sync.RWMutex
+	checks   []namedCheck
+	draining bool
+}
+
+func (h *health) add(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+func (h *health) drain() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.draining = true
+}
+
+func (h *health) live(w http.ResponseWriter, _ *http.Request) {
+	writeHealth(w, http.StatusOK, Health{Status: statusOK})
+}
+
+func (h *health) ready(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	checks := h.checks
+	draining := h.draining
+	h.mu.RUnlock()
+
+	if draining {
+		writeHealth(w, http.StatusServiceUnavailable, Health{Status: statusDraining})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			result := CheckResult{Status: statusOK}
+			if err := c.check(ctx); err != nil {
+				result.Status = statusUnavailable
+				result.Error = err.Error()
+			}
+			result.Duration = time.Since(start).String()
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	resp := Health{Status: statusOK, Checks: map[string]CheckResult{}}
+	status := http.StatusOK
+	for i, c := range checks {
+		resp.Checks[c.name] = results[i]
+		if results[i].Status != statusOK {
+			resp.Status = statusUnavailable
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	writeHealth(w, status, resp)
+}
+
+func writeHealth(w http.ResponseWriter, status int, resp Health) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	body, _ := json.Marshal(resp)
+	w.Write(body)
+}
@@ -1,10 +1,14 @@
 package http_server
 
 import (
+	"company-crud/pkg/logger"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 	swaggo "github.com/swaggo/http-swagger/v2"
 	"net/http"
@@ -12,19 +16,36 @@ import (
 )
 
 type Server struct {
-	router *mux.Router
-	server *http.Server
+	router   *mux.Router
+	server   *http.Server
+	registry *prometheus.Registry
+	health   *health
 }
 
 type GroupRouter interface {
 	AddRoute(r *mux.Router)
 }
 
-func New(withSwagger, withCors bool) *Server {
+func New(log *logger.Logger, withSwagger, withCors, withMetrics bool) *Server {
 	srv := &http.Server{}
 	srv.Addr = `:` + strconv.Itoa(8000)
 
 	router := mux.NewRouter()
+	router.Use(recordRoute)
+
+	health := &health{}
+	router.HandleFunc("/healthz", health.live).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", health.ready).Methods(http.MethodGet)
+
+	var handler http.Handler = router
+	var registry *prometheus.Registry
+	if withMetrics {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+		handler = newMetrics(registry).middleware(handler)
+		router.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{})).Methods(http.MethodGet)
+	}
 
 	if withSwagger {
 		c := cors.New(cors.Options{
@@ -38,15 +59,45 @@ func New(withSwagger, withCors bool) *Server {
 			swaggo.DomID("swagger-ui"),
 		)).Methods(http.MethodGet)
 
-		srv.Handler = c.Handler(router)
-	} else {
-		srv.Handler = router
+		handler = c.Handler(handler)
 	}
 
+	// Wrapping the router, rather than being a middleware of it, the access log and the
+	// metrics see the requests matching no route as well.
+	srv.Handler = accessLog(log)(handler)
+
 	return &Server{
-		router: router,
-		server: srv,
+		router:   router,
+		server:   srv,
+		registry: registry,
+		health:   health,
+	}
+}
+
+// AddReadinessCheck makes /readyz depend on check.
+func (s *Server) AddReadinessCheck(name string, check Check) {
+	s.health.add(name, check)
+}
+
+// Drain flips /readyz to not ready, so that no new traffic is routed to the server while
+// the requests in flight complete.
+func (s *Server) Drain() {
+	s.health.drain()
+}
+
+// RegisterMetrics exposes collectors on /metrics. It does nothing when metrics are disabled.
+func (s *Server) RegisterMetrics(cs ...prometheus.Collector) error {
+	if s.registry == nil {
+		return nil
 	}
+
+	for _, c := range cs {
+		if err := s.registry.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (s *Server) CreateRoutes(groupRoutes ...GroupRouter) {
@@ -0,0 +1,185 @@
+package jwtkeys
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+)
+
+// maxJWKSSize bounds the JWKS documents read, they hold a handful of keys.
+const maxJWKSSize = 1 << 20
+
+// jwk is a JSON Web Key, see RFC 7517 and RFC 7518.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+func loadJWKSFile(path string) ([]Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading JWKS: %w", err)
+	}
+
+	return parseJWKS(data)
+}
+
+func fetchJWKS(ctx context.Context, client *http.Client, url string) ([]Key, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error while fetching JWKS: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error while fetching JWKS: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("error while fetching JWKS: %w", err)
+	}
+
+	return parseJWKS(data)
+}
+
+// parseJWKS returns the signature keys of a JWKS document. Keys for encryption are left
+// out, an invalid or symmetric signature key fails the whole document.
+func parseJWKS(data []byte) ([]Key, error) {
+	set := jwks{}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("error while decoding JWKS: %w", err)
+	}
+
+	keys := make([]Key, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %q: %w", k.Kid, err)
+		}
+
+		keys = append(keys, Key{ID: k.Kid, Alg: k.Alg, Key: key})
+	}
+
+	return keys, nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		return k.ecPublicKey()
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size")
+		}
+
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		// The JWKS documents are the public keys of issuers signing with private ones. A
+		// secret found there would let anyone able to read them mint tokens.
+		return nil, fmt.Errorf("symmetric keys aren't accepted from JWKS, use the HMAC secrets")
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func (k jwk) ecPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	var ecdhCurve ecdh.Curve
+	switch k.Crv {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	if len(x) != size || len(y) != size {
+		return nil, fmt.Errorf("invalid %s coordinates size", k.Crv)
+	}
+
+	// ecdh rejects the points that aren't on the curve.
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("missing key parameter")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,327 @@
+package jwtkeys
+
+import (
+	"company-crud/pkg/logger"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const errorSection = "jwtKeys"
+
+const (
+	defaultRefreshInterval = 15 * time.Minute
+	// minRefreshInterval rate limits the refreshes triggered by tokens with an unknown kid.
+	minRefreshInterval = 10 * time.Second
+	fetchTimeout       = 10 * time.Second
+)
+
+var UnknownKey = errors.New("no key to verify the token")
+
+type Config struct {
+	// HMACSecrets are all valid at once, so that a new secret can be rolled out before the
+	// old one is retired.
+	HMACSecrets []string
+	// PublicKeyFiles are PEM encoded RSA, ECDSA or Ed25519 public keys or certificates. The
+	// name of the file, without extension, is the kid of its key.
+	PublicKeyFiles []string
+	// JWKSFile and JWKSURL point to JWKS documents, reloaded every RefreshInterval.
+	JWKSFile        string
+	JWKSURL         string
+	RefreshInterval time.Duration
+}
+
+// Key verifies the tokens signed with the matching secret or private key. ID and Alg
+// restrict it to the tokens with that kid and alg when set.
+type Key struct {
+	ID  string
+	Alg string
+	Key interface{}
+}
+
+// KeySet selects the key verifying a token, by kid when the token has one.
+type KeySet struct {
+	cfg    Config
+	logger *logger.Logger
+	client *http.Client
+
+	static []Key
+
+	mu   sync.RWMutex
+	jwks []Key
+	// refreshedAt is when the JWKS documents were last reloaded, successfully or not.
+	refreshedAt time.Time
+
+	refreshMu sync.Mutex
+	// refreshing is set while a refresh triggered by an unknown kid runs.
+	refreshing atomic.Bool
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// New loads every configured key, failing when any can't be, and keeps the JWKS
+// documents refreshed until Stop.
+func New(cfg Config, log *logger.Logger) (*KeySet, error) {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaultRefreshInterval
+	}
+
+	ks := &KeySet{
+		cfg:    cfg,
+		logger: log,
+		client: &http.Client{Timeout: fetchTimeout},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	for _, secret := range cfg.HMACSecrets {
+		if secret != "" {
+			ks.static = append(ks.static, Key{Key: []byte(secret)})
+		}
+	}
+
+	for _, path := range cfg.PublicKeyFiles {
+		key, err := loadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		ks.static = append(ks.static, key)
+	}
+
+	if cfg.JWKSFile == "" && cfg.JWKSURL == "" {
+		close(ks.done)
+		return ks, nil
+	}
+
+	if err := ks.refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	go ks.refreshLoop()
+
+	return ks, nil
+}
+
+// Stop ends the JWKS refreshes.
+func (ks *KeySet) Stop() {
+	select {
+	case <-ks.stop:
+	default:
+		close(ks.stop)
+	}
+	<-ks.done
+}
+
+func (ks *KeySet) refreshLoop() {
+	defer close(ks.done)
+
+	ticker := time.NewTicker(ks.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := ks.refresh(context.Background()); err != nil {
+				// The keys loaded last keep being used meanwhile.
+				ks.logger.Named(errorSection).Warn("refreshing JWKS failed", zap.Error(err))
+			}
+		case <-ks.stop:
+			return
+		}
+	}
+}
+
+// refresh reloads the JWKS documents, replacing the keys loaded from them.
+func (ks *KeySet) refresh(ctx context.Context) error {
+	ks.refreshMu.Lock()
+	defer ks.refreshMu.Unlock()
+
+	ks.mu.Lock()
+	ks.refreshedAt = time.Now()
+	ks.mu.Unlock()
+
+	var keys []Key
+	if ks.cfg.JWKSFile != "" {
+		fileKeys, err := loadJWKSFile(ks.cfg.JWKSFile)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, fileKeys...)
+	}
+	if ks.cfg.JWKSURL != "" {
+		urlKeys, err := fetchJWKS(ctx, ks.client, ks.cfg.JWKSURL)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, urlKeys...)
+	}
+
+	ks.mu.Lock()
+	ks.jwks = keys
+	ks.mu.Unlock()
+
+	ks.logger.Named(errorSection).Debug(fmt.Sprintf("%d JWKS keys loaded", len(keys)))
+
+	return nil
+}
+
+// Keyfunc is a jwt.Keyfunc. A token with a kid is verified with the key of that kid, or
+// else with the keys that have none, e.g. the HMAC secrets. An unknown kid triggers a
+// refresh of the JWKS documents in the background, rather than holding the request, so
+// that a token signed with a key just rotated in is accepted shortly after. A token
+// without kid is verified with every compatible key.
+func (ks *KeySet) Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return ks.candidates(t.Method, func(Key) bool { return true })
+	}
+
+	withKid := func(key Key) bool { return key.ID == kid }
+
+	keys, err := ks.candidates(t.Method, withKid)
+	if err == nil {
+		return keys, nil
+	}
+
+	ks.refreshInBackground()
+
+	return ks.candidates(t.Method, func(key Key) bool { return key.ID == "" })
+}
+
+// refreshInBackground reloads the JWKS documents without waiting for them, unless a
+// refresh already runs or ran too recently.
+func (ks *KeySet) refreshInBackground() {
+	if !ks.refreshable() || !ks.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer ks.refreshing.Store(false)
+
+		if err := ks.refresh(context.Background()); err != nil {
+			ks.logger.Named(errorSection).Warn("refreshing JWKS failed", zap.Error(err))
+		}
+	}()
+}
+
+// refreshable tells whether a JWKS document may be reloaded now.
+func (ks *KeySet) refreshable() bool {
+	if ks.cfg.JWKSFile == "" && ks.cfg.JWKSURL == "" {
+		return false
+	}
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return time.Since(ks.refreshedAt) >= minRefreshInterval
+}
+
+// candidates returns the selected keys which can verify tokens signed with method, or
+// UnknownKey when there is none.
+func (ks *KeySet) candidates(method jwt.SigningMethod, selected func(Key) bool) (jwt.VerificationKeySet, error) {
+	ks.mu.RLock()
+	keys := append(append([]Key{}, ks.static...), ks.jwks...)
+	ks.mu.RUnlock()
+
+	set := jwt.VerificationKeySet{}
+	for _, key := range keys {
+		if !selected(key) || !compatible(method, key) {
+			continue
+		}
+		if key.Alg != "" && key.Alg != method.Alg() {
+			continue
+		}
+		set.Keys = append(set.Keys, key.Key)
+	}
+
+	if len(set.Keys) == 0 {
+		return set, UnknownKey
+	}
+
+	return set, nil
+}
+
+// compatible tells whether key is of the type method verifies with.
+func compatible(method jwt.SigningMethod, key Key) bool {
+	switch m := method.(type) {
+	case *jwt.SigningMethodHMAC:
+		_, ok := key.Key.([]byte)
+		return ok
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok := key.Key.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		ecKey, ok := key.Key.(*ecdsa.PublicKey)
+		return ok && ecKey.Curve.Params().BitSize == m.CurveBits
+	case *jwt.SigningMethodEd25519:
+		_, ok := key.Key.(ed25519.PublicKey)
+		return ok
+	}
+
+	return false
+}
+
+// Algorithms are the signing algorithms keys can be used with.
+func Algorithms() []string {
+	return []string{
+		jwt.SigningMethodHS256.Alg(), jwt.SigningMethodHS384.Alg(), jwt.SigningMethodHS512.Alg(),
+		jwt.SigningMethodRS256.Alg(), jwt.SigningMethodRS384.Alg(), jwt.SigningMethodRS512.Alg(),
+		jwt.SigningMethodPS256.Alg(), jwt.SigningMethodPS384.Alg(), jwt.SigningMethodPS512.Alg(),
+		jwt.SigningMethodES256.Alg(), jwt.SigningMethodES384.Alg(), jwt.SigningMethodES512.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+	}
+}
+
+// loadPublicKey reads a PEM encoded public key or certificate.
+func loadPublicKey(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("error while reading public key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("%s: no PEM block found", path)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, certErr := x509.ParseCertificate(block.Bytes)
+		if certErr != nil {
+			return Key{}, fmt.Errorf("%s: %w", path, certErr)
+		}
+		key = cert.PublicKey
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+	default:
+		return Key{}, fmt.Errorf("%s: unsupported key type %T", path, key)
+	}
+
+	return Key{
+		ID:  strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Key: key,
+	}, nil
+}
@@ -0,0 +1,22 @@
+package logger
+
+import (
+	"context"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying log, to be picked up by For.
+func NewContext(ctx context.Context, log *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, log)
+}
+
+// For returns the logger carried by ctx, e.g. one scoped to the request being served, and
+// falls back to l when there is none.
+func (l *Logger) For(ctx context.Context) *Logger {
+	if log, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return log
+	}
+
+	return l
+}
@@ -0,0 +1,342 @@
+package migrate
+
+import (
+	"company-crud/pkg/logger"
+	"company-crud/pkg/postres"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const errorSection = "migrate"
+
+// lockID is the advisory lock serializing migrators across instances.
+const lockID = 7_000_002
+
+var UnknownVersion = errors.New("unknown migration version")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrator applies the migrations found in a file system, one transaction each, and keeps
+// track of them in public.schema_migrations.
+type Migrator struct {
+	db         *postres.Postgres
+	logger     *logger.Logger
+	migrations []Migration
+}
+
+func New(db *postres.Postgres, log *logger.Logger, fsys fs.FS) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		logger:     log,
+		migrations: migrations,
+	}, nil
+}
+
+// load reads the <version>_<name>.up.sql and <version>_<name>.down.sql pairs of fsys,
+// sorted by version.
+func load(fsys fs.FS) ([]Migration, error) {
+	pattern := regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+	files, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, file := range files {
+		match := pattern.FindStringSubmatch(file.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", file.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, file.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has more than one name", version)
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d misses its up or down file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest is the version of the newest known migration, zero when there is none.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down rolls back the latest applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		current, err := currentVersion(ctx, conn)
+		if err != nil || current == 0 {
+			return err
+		}
+
+		return m.migrateTo(ctx, conn, current, m.previous(current))
+	})
+}
+
+// To applies or rolls back migrations until version is the latest applied one. Zero rolls
+// back everything.
+func (m *Migrator) To(ctx context.Context, version int) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("%w: %d", UnknownVersion, version)
+	}
+
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		current, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		return m.migrateTo(ctx, conn, current, version)
+	})
+}
+
+// Status lists the known migrations along with when they were applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedAt(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if at, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &at
+			}
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+func (m *Migrator) migrateTo(ctx context.Context, conn *sql.Conn, current, target int) error {
+	log := m.logger.Named(errorSection)
+
+	// The schema was migrated by a newer build, which is the only one able to roll it back.
+	if current != 0 && m.find(current) == nil {
+		return fmt.Errorf("%w: database is at version %d", UnknownVersion, current)
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version <= current || migration.Version > target {
+			continue
+		}
+
+		err := inTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+				return err
+			}
+
+			_, err := tx.ExecContext(ctx, `INSERT INTO public.schema_migrations (version, name) VALUES ($1, $2)`,
+				migration.Version, migration.Name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("applying migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		log.Info(fmt.Sprintf("applied migration %d_%s", migration.Version, migration.Name))
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > current || migration.Version <= target {
+			continue
+		}
+
+		err := inTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return err
+			}
+
+			_, err := tx.ExecContext(ctx, `DELETE FROM public.schema_migrations WHERE version = $1`, migration.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("rolling back migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		log.Info(fmt.Sprintf("rolled back migration %d_%s", migration.Version, migration.Name))
+	}
+
+	return nil
+}
+
+// locked runs fn on a dedicated connection holding the migration lock, once the
+// bookkeeping table exists.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Neither waiting for the lock nor building an index is bound by the query timeout. The
+	// connection goes back to the pool afterwards, with the timeout it had.
+	if _, err := conn.ExecContext(ctx, `SET statement_timeout = 0`); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `RESET statement_timeout`); err != nil {
+			m.logger.Named(errorSection).Error(err.Error())
+			// Closing the driver connection keeps it from being reused without a timeout.
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockID); err != nil {
+			m.logger.Named(errorSection).Error(err.Error())
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS public.schema_migrations
+		(
+			version    BIGINT PRIMARY KEY,
+			name       TEXT                      NOT NULL,
+			applied_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+		)`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+
+	return nil
+}
+
+// previous is the version preceding version, zero for the first one.
+func (m *Migrator) previous(version int) int {
+	previous := 0
+	for _, migration := range m.migrations {
+		if migration.Version >= version {
+			break
+		}
+		previous = migration.Version
+	}
+
+	return previous
+}
+
+func currentVersion(ctx context.Context, conn *sql.Conn) (int, error) {
+	var version sql.NullInt64
+	err := conn.QueryRowContext(ctx, `SELECT MAX(version) FROM public.schema_migrations`).Scan(&version)
+
+	return int(version.Int64), err
+}
+
+func appliedAt(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM public.schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+
+	return applied, rows.Err()
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
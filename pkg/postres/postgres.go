@@ -1,24 +1,38 @@
 package postres
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"time"
 )
 
 var (
 	DuplicateKey                     = errors.New("duplicate key")
 	InvalidArgumentsForBuildingquery = errors.New("invalid arguments for building a query")
+	InvalidCursor                    = errors.New("invalid cursor")
 	NoRowsErr                        = errors.New("no rows")
+	NotApplied                       = errors.New("not applied, another item of the batch failed")
+	PreconditionFailed               = errors.New("precondition failed")
+	QueryTimeout                     = errors.New("query timeout")
 )
 
+// queryCanceled is the SQLSTATE of a statement canceled by statement_timeout.
+const queryCanceled = "57014"
+
 type Config struct {
 	DBHost     string
 	DBPort     int
 	DBUsername string
 	DBPassword string
 	DBName     string
+	// QueryTimeout makes the server cancel any statement running longer, zero disables it.
+	QueryTimeout time.Duration
 }
 
 type Postgres struct {
@@ -34,11 +48,15 @@ func New(conf Config) (*Postgres, error) {
 		conf.DBPassword,
 		conf.DBName,
 	)
+	if conf.QueryTimeout > 0 {
+		postgresConn += fmt.Sprintf(" statement_timeout=%d", conf.QueryTimeout.Milliseconds())
+	}
 
-	db, err := sqlx.Open("postgres", postgresConn)
+	connector, err := pq.NewConnector(postgresConn)
 	if err != nil {
 		return nil, fmt.Errorf("error opening connection with db %w", err)
 	}
+	db := sqlx.NewDb(sql.OpenDB(tracedConnector{Connector: connector, dbName: conf.DBName}), "postgres")
 
 	if err = db.Ping(); err != nil {
 		return nil, fmt.Errorf("error pinging db %w", err)
@@ -50,6 +68,61 @@ func New(conf Config) (*Postgres, error) {
 	}, err
 }
 
+// InTx runs fn in a transaction, which is committed when fn returns nil and rolled back
+// otherwise. The transaction is rolled back as well if ctx is done before it commits.
+func (p *Postgres) InTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	return p.inTx(ctx, nil, fn)
+}
+
+// InSnapshot runs fn in a read only transaction whose statements all see the data as it
+// was when the first of them started.
+func (p *Postgres) InSnapshot(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	return p.inTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, fn)
+}
+
+func (p *Postgres) inTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *sqlx.Tx) error) error {
+	tx, err := p.BeginTxx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ContextErr tells apart the ways a query run under ctx may have been cut short: ctx ending
+// gives an error matching ctx.Err(), the server side statement timeout QueryTimeout.
+func ContextErr(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if errors.Is(err, ctxErr) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ctxErr, err)
+	}
+
+	var pgErr *pq.Error
+	if errors.As(err, &pgErr) && pgErr.Code == queryCanceled {
+		return fmt.Errorf("%w: %w", QueryTimeout, err)
+	}
+
+	return err
+}
+
+// Collector reports the connection pool stats.
+func (p *Postgres) Collector() prometheus.Collector {
+	return collectors.NewDBStatsCollector(p.DB.DB, p.cfg.DBName)
+}
+
 func (p *Postgres) Stop() error {
 	return p.Close()
 }
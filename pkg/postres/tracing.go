@@ -0,0 +1,224 @@
+package postres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"reflect"
+	"strings"
+)
+
+const tracerName = "company-crud/pkg/postres"
+
+// tracedConnector wraps the connections of a driver.Connector so that every statement run
+// through them is recorded as a span, transactions included.
+type tracedConnector struct {
+	driver.Connector
+	dbName string
+}
+
+func (tc tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := tc.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tracedConn{
+		Conn:   conn,
+		tracer: otel.Tracer(tracerName),
+		dbName: tc.dbName,
+	}, nil
+}
+
+type tracedConn struct {
+	driver.Conn
+	tracer trace.Tracer
+	dbName string
+}
+
+func (tc *tracedConn) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := "SQL"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+
+	return tc.tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.name", tc.dbName),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", query),
+		),
+	)
+}
+
+func end(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, driver.ErrSkip) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (tc *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := tc.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, span := tc.start(ctx, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	end(span, err)
+
+	return result, err
+}
+
+func (tc *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := tc.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, span := tc.start(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		end(span, err)
+		return nil, err
+	}
+
+	// The span lasts until the rows are closed, reading them is part of the query.
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (tc *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := tc.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+
+	return tc.Conn.Prepare(query)
+}
+
+func (tc *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := tc.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+
+	return tc.Conn.Begin()
+}
+
+func (tc *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := tc.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (tc *tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := tc.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (tc *tracedConn) IsValid() bool {
+	if validator, ok := tc.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+// tracedRows ends the span of their query once closed, recording how many rows were read
+// and the error that stopped the reading, if any.
+type tracedRows struct {
+	driver.Rows
+	span trace.Span
+	read int
+	err  error
+}
+
+func (tr *tracedRows) Next(dest []driver.Value) error {
+	err := tr.Rows.Next(dest)
+	switch {
+	case err == nil:
+		tr.read++
+	case !errors.Is(err, io.EOF):
+		tr.err = err
+	}
+
+	return err
+}
+
+func (tr *tracedRows) Close() error {
+	err := tr.Rows.Close()
+	tr.span.SetAttributes(attribute.Int("db.rows_read", tr.read))
+	end(tr.span, errors.Join(tr.err, err))
+
+	return err
+}
+
+// The optional interfaces of driver.Rows are passed through, database/sql asserts them.
+
+func (tr *tracedRows) HasNextResultSet() bool {
+	if rows, ok := tr.Rows.(driver.RowsNextResultSet); ok {
+		return rows.HasNextResultSet()
+	}
+
+	return false
+}
+
+func (tr *tracedRows) NextResultSet() error {
+	if rows, ok := tr.Rows.(driver.RowsNextResultSet); ok {
+		return rows.NextResultSet()
+	}
+
+	return io.EOF
+}
+
+func (tr *tracedRows) ColumnTypeScanType(index int) reflect.Type {
+	if rows, ok := tr.Rows.(driver.RowsColumnTypeScanType); ok {
+		return rows.ColumnTypeScanType(index)
+	}
+
+	return reflect.TypeOf(new(any)).Elem()
+}
+
+func (tr *tracedRows) ColumnTypeDatabaseTypeName(index int) string {
+	if rows, ok := tr.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return rows.ColumnTypeDatabaseTypeName(index)
+	}
+
+	return ""
+}
+
+func (tr *tracedRows) ColumnTypeLength(index int) (int64, bool) {
+	if rows, ok := tr.Rows.(driver.RowsColumnTypeLength); ok {
+		return rows.ColumnTypeLength(index)
+	}
+
+	return 0, false
+}
+
+func (tr *tracedRows) ColumnTypeNullable(index int) (bool, bool) {
+	if rows, ok := tr.Rows.(driver.RowsColumnTypeNullable); ok {
+		return rows.ColumnTypeNullable(index)
+	}
+
+	return false, false
+}
+
+func (tr *tracedRows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if rows, ok := tr.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return rows.ColumnTypePrecisionScale(index)
+	}
+
+	return 0, 0, false
+}
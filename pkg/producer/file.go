@@ -0,0 +1,72 @@
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// fileRecord is a line of the file written by File. Values that aren't JSON are written
+// as a JSON string.
+type fileRecord struct {
+	Key     string            `json:"key"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Value   json.RawMessage   `json:"value"`
+}
+
+// File appends the published messages to a newline-delimited JSON file, which is handy to
+// watch the events locally without a broker.
+type File struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFile(path string) (*File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening event file: %w", err)
+	}
+
+	return &File{
+		file: file,
+	}, nil
+}
+
+func (f *File) ProduceEvent(_ context.Context, message Message) error {
+	value := json.RawMessage(message.Value)
+	if !json.Valid(message.Value) {
+		quoted, err := json.Marshal(string(message.Value))
+		if err != nil {
+			return err
+		}
+		value = quoted
+	}
+
+	line, err := json.Marshal(fileRecord{
+		Key:     string(message.Key),
+		Headers: message.Headers,
+		Value:   value,
+	})
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error while writing event: %w", err)
+	}
+
+	return nil
+}
+
+func (f *File) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.file.Sync()
+	f.file.Close()
+}
@@ -1,27 +1,119 @@
 package producer
 
 import (
+	"company-crud/pkg/logger"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"sync/atomic"
 	"time"
 )
 
+const errorSection = "kafkaProducer"
+const tracerName = "company-crud/pkg/producer"
+
+const (
+	defaultMaxRetries      = 5
+	defaultRetryBackoff    = 100 * time.Millisecond
+	defaultDeliveryTimeout = 30 * time.Second
+	maxRetryBackoff        = 5 * time.Second
+	flushTimeout           = 5 * time.Second
+)
+
 type Config struct {
 	Server string
 	Acks   string
 	Topic  string
+	// MaxRetries bounds how many times a message is produced again after a transient
+	// failure, waiting RetryBackoff, doubled on every attempt, in between.
+	MaxRetries   int
+	RetryBackoff time.Duration
+	// DeliveryTimeout bounds how long ProduceEvent waits for the broker ack, retries included.
+	DeliveryTimeout time.Duration
+}
+
+// Message is a record to publish. Key picks the partition, so messages sharing a key are
+// delivered in order.
+type Message struct {
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
 }
 
+// Produce publishes messages to an event sink. Stop flushes what is pending and releases it.
 type Produce interface {
-	ProduceEvent(message []byte) error
+	ProduceEvent(ctx context.Context, message Message) error
+	Stop()
+}
+
+// Stats counts the delivery outcomes since the producer was created.
+type Stats struct {
+	Produced  uint64
+	Delivered uint64
+	Failed    uint64
+	Retried   uint64
+}
+
+// Delivery resolves once the broker acked, or definitely refused, a message.
+type Delivery struct {
+	done      chan struct{}
+	err       error
+	partition kafka.TopicPartition
+}
+
+// Done is closed once the delivery report is received.
+func (d *Delivery) Done() <-chan struct{} {
+	return d.done
+}
+
+// Wait blocks until the delivery report is received or ctx is done. A message whose wait
+// timed out may still be delivered later.
+func (d *Delivery) Wait(ctx context.Context) error {
+	select {
+	case <-d.done:
+		return d.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Partition is where the message was written, once Done.
+func (d *Delivery) Partition() kafka.TopicPartition {
+	return d.partition
 }
 
 type KafkaProducer struct {
 	*kafka.Producer
-	cfg Config
+	cfg    Config
+	logger *logger.Logger
+	tracer trace.Tracer
+
+	produced  atomic.Uint64
+	delivered atomic.Uint64
+	failed    atomic.Uint64
+	retried   atomic.Uint64
+
+	reportsDone chan struct{}
 }
 
-func New(conf Config) (*KafkaProducer, error) {
+func New(conf Config, log *logger.Logger) (*KafkaProducer, error) {
+	if conf.MaxRetries <= 0 {
+		conf.MaxRetries = defaultMaxRetries
+	}
+	if conf.RetryBackoff <= 0 {
+		conf.RetryBackoff = defaultRetryBackoff
+	}
+	if conf.DeliveryTimeout <= 0 {
+		conf.DeliveryTimeout = defaultDeliveryTimeout
+	}
+
 	p, err := kafka.NewProducer(&kafka.ConfigMap{
 		"bootstrap.servers": conf.Server,
 		"acks":              conf.Acks,
@@ -30,41 +122,192 @@ func New(conf Config) (*KafkaProducer, error) {
 		return nil, err
 	}
 
-	return &KafkaProducer{
-		Producer: p,
-		cfg:      conf,
-	}, nil
+	kp := &KafkaProducer{
+		Producer:    p,
+		cfg:         conf,
+		logger:      log,
+		tracer:      otel.Tracer(tracerName),
+		reportsDone: make(chan struct{}),
+	}
+	go kp.deliveryReports()
+
+	return kp, nil
+}
+
+// deliveryReports resolves the delivery of every produced message. It runs until the
+// producer is closed.
+func (kp *KafkaProducer) deliveryReports() {
+	defer close(kp.reportsDone)
+	log := kp.logger.Named(errorSection)
+
+	for e := range kp.Events() {
+		switch ev := e.(type) {
+		case *kafka.Message:
+			delivery, ok := ev.Opaque.(*Delivery)
+			if !ok {
+				continue
+			}
+
+			delivery.partition = ev.TopicPartition
+			delivery.err = ev.TopicPartition.Error
+			if delivery.err != nil {
+				kp.failed.Add(1)
+				log.Warn("delivery failed", zap.String("topic_partition", ev.TopicPartition.String()), zap.Error(delivery.err))
+			} else {
+				kp.delivered.Add(1)
+				log.Debug("message delivered", zap.String("topic_partition", ev.TopicPartition.String()))
+			}
+			close(delivery.done)
+		case kafka.Error:
+			log.Error(ev.Error(), zap.Bool("fatal", ev.IsFatal()))
+		}
+	}
 }
 
 func (kp *KafkaProducer) Stop() {
-	kp.Flush(int(5 * time.Second))
+	if remaining := kp.Flush(int(flushTimeout.Milliseconds())); remaining > 0 {
+		kp.logger.Named(errorSection).Warn(fmt.Sprintf("%d messages not delivered on shutdown", remaining))
+	}
 	kp.Close()
+	<-kp.reportsDone
 }
 
-func (kp *KafkaProducer) ProduceEvent(message []byte) error {
-	go func() {
-		for e := range kp.Events() {
-			switch ev := e.(type) {
-			case *kafka.Message:
-				if ev.TopicPartition.Error != nil {
-					fmt.Printf("Delivery failed: %v\n", ev.TopicPartition)
-				} else {
-					fmt.Printf("Delivered message to %v\n", ev.TopicPartition)
-				}
-			}
-		}
-	}()
+// Ping checks that the broker answers with the metadata of the topic, within the ctx
+// deadline or a second when there is none.
+func (kp *KafkaProducer) Ping(ctx context.Context) error {
+	timeout := time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if timeout <= 0 {
+		return context.DeadlineExceeded
+	}
+
+	metadata, err := kp.GetMetadata(&kp.cfg.Topic, false, int(timeout.Milliseconds()))
+	if err != nil {
+		return fmt.Errorf("error while fetching metadata: %w", err)
+	}
+
+	topic, ok := metadata.Topics[kp.cfg.Topic]
+	if !ok {
+		return fmt.Errorf("topic %s not found", kp.cfg.Topic)
+	}
+	if topic.Error.Code() != kafka.ErrNoError {
+		return fmt.Errorf("topic %s: %w", kp.cfg.Topic, topic.Error)
+	}
+
+	return nil
+}
+
+// Stats returns the delivery counters.
+func (kp *KafkaProducer) Stats() Stats {
+	return Stats{
+		Produced:  kp.produced.Load(),
+		Delivered: kp.delivered.Load(),
+		Failed:    kp.failed.Load(),
+		Retried:   kp.retried.Load(),
+	}
+}
+
+// Publish enqueues message and returns its delivery, without waiting for the ack.
+func (kp *KafkaProducer) Publish(message Message) (*Delivery, error) {
+	headers := make([]kafka.Header, 0, len(message.Headers))
+	for key, value := range message.Headers {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
 
+	delivery := &Delivery{done: make(chan struct{})}
 	err := kp.Produce(&kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &kp.cfg.Topic, Partition: kafka.PartitionAny},
-		Value:          []byte(message),
+		Key:            message.Key,
+		Value:          message.Value,
+		Headers:        headers,
+		Opaque:         delivery,
 	}, nil)
 	if err != nil {
-		if err.(kafka.Error).Code() == kafka.ErrQueueFull {
-			time.Sleep(time.Second)
+		return nil, fmt.Errorf("error while producing event: %w", err)
+	}
+	kp.produced.Add(1)
+
+	return delivery, nil
+}
+
+// ProduceEvent publishes message and waits for the broker ack, producing it again with
+// exponential backoff on transient failures. It gives up once ctx is done or the
+// DeliveryTimeout elapsed.
+func (kp *KafkaProducer) ProduceEvent(ctx context.Context, message Message) (err error) {
+	ctx, span := kp.tracer.Start(ctx, kp.cfg.Topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", kp.cfg.Topic),
+			attribute.String("messaging.kafka.message.key", string(message.Key)),
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
 		}
-		return fmt.Errorf("error while producing event: %w", err)
+		span.End()
+	}()
+
+	// Consumers continue the trace from the producer span.
+	headers := make(map[string]string, len(message.Headers)+1)
+	for key, value := range message.Headers {
+		headers[key] = value
 	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+	message.Headers = headers
 
-	return nil
+	ctx, cancel := context.WithTimeout(ctx, kp.cfg.DeliveryTimeout)
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+		delivery, err := kp.Publish(message)
+		if err == nil {
+			err = delivery.Wait(ctx)
+		}
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= kp.cfg.MaxRetries || !retriable(err) {
+			return err
+		}
+
+		kp.retried.Add(1)
+		wait := backoff(kp.cfg.RetryBackoff, attempt)
+		kp.logger.Named(errorSection).Debug(fmt.Sprintf("retrying in %s", wait), zap.Int("attempt", attempt+1), zap.Error(err))
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+	}
+}
+
+// retriable reports whether producing the message again may succeed.
+func retriable(err error) bool {
+	var kafkaErr kafka.Error
+	if !errors.As(err, &kafkaErr) {
+		return false
+	}
+
+	switch kafkaErr.Code() {
+	case kafka.ErrQueueFull, kafka.ErrMsgTimedOut, kafka.ErrTimedOut, kafka.ErrTransport, kafka.ErrAllBrokersDown:
+		return true
+	}
+
+	return kafkaErr.IsRetriable()
+}
+
+func backoff(base time.Duration, attempt int) time.Duration {
+	wait := base
+	for i := 0; i < attempt && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+
+	return min(wait, maxRetryBackoff)
 }
@@ -0,0 +1,30 @@
+package producer
+
+import (
+	"company-crud/pkg/logger"
+	"context"
+	"go.uber.org/zap"
+)
+
+// Log drops the published messages, only logging them at debug level.
+type Log struct {
+	logger *logger.Logger
+}
+
+func NewLog(log *logger.Logger) *Log {
+	return &Log{
+		logger: log,
+	}
+}
+
+func (l *Log) ProduceEvent(_ context.Context, message Message) error {
+	l.logger.Named("logProducer").Debug("event published",
+		zap.ByteString("key", message.Key),
+		zap.Any("headers", message.Headers),
+		zap.ByteString("value", message.Value),
+	)
+
+	return nil
+}
+
+func (l *Log) Stop() {}
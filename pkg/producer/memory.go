@@ -0,0 +1,61 @@
+package producer
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory keeps the published messages in memory, to be inspected by tests.
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) ProduceEvent(_ context.Context, message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+
+	return nil
+}
+
+func (m *Memory) Stop() {}
+
+// Messages returns a copy of the messages published so far, oldest first.
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := make([]Message, len(m.messages))
+	copy(messages, m.messages)
+
+	return messages
+}
+
+// ByKey returns the messages published with key, oldest first.
+func (m *Memory) ByKey(key string) []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var messages []Message
+	for _, message := range m.messages {
+		if string(message.Key) == key {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages
+}
+
+// Reset drops the messages published so far.
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
@@ -0,0 +1,47 @@
+package producer
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// collector reports the delivery counters and the number of messages waiting in the
+// producer queue.
+type collector struct {
+	kp *KafkaProducer
+
+	produced  *prometheus.Desc
+	delivered *prometheus.Desc
+	failed    *prometheus.Desc
+	retried   *prometheus.Desc
+	queue     *prometheus.Desc
+}
+
+// Collector exposes the producer stats as Prometheus metrics.
+func (kp *KafkaProducer) Collector() prometheus.Collector {
+	labels := prometheus.Labels{"topic": kp.cfg.Topic}
+
+	return &collector{
+		kp:        kp,
+		produced:  prometheus.NewDesc("kafka_producer_messages_produced_total", "Messages handed to the producer.", nil, labels),
+		delivered: prometheus.NewDesc("kafka_producer_messages_delivered_total", "Messages acked by the broker.", nil, labels),
+		failed:    prometheus.NewDesc("kafka_producer_messages_failed_total", "Messages the broker failed to ack.", nil, labels),
+		retried:   prometheus.NewDesc("kafka_producer_messages_retried_total", "Messages produced again after a transient failure.", nil, labels),
+		queue:     prometheus.NewDesc("kafka_producer_queue_length", "Messages waiting to be delivered.", nil, labels),
+	}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.produced
+	ch <- c.delivered
+	ch <- c.failed
+	ch <- c.retried
+	ch <- c.queue
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.kp.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.produced, prometheus.CounterValue, float64(stats.Produced))
+	ch <- prometheus.MustNewConstMetric(c.delivered, prometheus.CounterValue, float64(stats.Delivered))
+	ch <- prometheus.MustNewConstMetric(c.failed, prometheus.CounterValue, float64(stats.Failed))
+	ch <- prometheus.MustNewConstMetric(c.retried, prometheus.CounterValue, float64(stats.Retried))
+	ch <- prometheus.MustNewConstMetric(c.queue, prometheus.GaugeValue, float64(c.kp.Len()))
+}
@@ -0,0 +1,20 @@
+// Package requestid carries the id of the request being served, so that the layers below
+// the HTTP server can record it without depending on it.
+package requestid
+
+import (
+	"context"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying id, to be picked up by FromContext.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the id of the request served with ctx, or "" outside of one.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
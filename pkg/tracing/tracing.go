@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"io"
+	"os"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+type Config struct {
+	ServiceName string
+	// Exporter is one of none, otlp, stdout or file.
+	Exporter string
+	// OTLPEndpoint is the host:port of the collector receiving OTLP over HTTP.
+	OTLPEndpoint string
+	OTLPInsecure bool
+	// File receives the spans, one JSON document each, with the file exporter.
+	File string
+	// SampleRatio is the share of new traces recorded; traces started upstream follow the
+	// caller's decision.
+	SampleRatio float64
+}
+
+// Tracing installs the global tracer provider and the W3C trace context propagator.
+type Tracing struct {
+	provider *sdktrace.TracerProvider
+	output   io.Closer
+}
+
+func New(ctx context.Context, cfg Config) (*Tracing, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	t := &Tracing{}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		// Spans aren't recorded, but the incoming trace context is still passed on.
+		return t, nil
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		var file *os.File
+		file, err = os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("error while opening trace file: %w", err)
+		}
+		t.output = file
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	default:
+		return nil, fmt.Errorf("invalid exporter %q, expected one of none, otlp, stdout, file", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while creating trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(t.provider)
+
+	return t, nil
+}
+
+// Stop flushes the spans not exported yet.
+func (t *Tracing) Stop(ctx context.Context) error {
+	var err error
+	if t.provider != nil {
+		err = t.provider.Shutdown(ctx)
+	}
+	if t.output != nil {
+		err = errors.Join(err, t.output.Close())
+	}
+
+	return err
+}
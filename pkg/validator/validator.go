@@ -1,16 +1,106 @@
 package validator
 
 import (
+	"errors"
+	"fmt"
 	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
 )
 
 type Validator struct {
 	*validator.Validate
 }
 
+// FieldViolation describes a single rule a field failed.
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// FieldError reports violations found outside struct tags, e.g. by a lookup done by the caller.
+type FieldError struct {
+	Violations []FieldViolation
+}
+
+func NewFieldError(field, rule, message string) *FieldError {
+	return &FieldError{
+		Violations: []FieldViolation{{
+			Field:   field,
+			Rule:    rule,
+			Message: message,
+		}},
+	}
+}
+
+func (fe *FieldError) Error() string {
+	messages := make([]string, 0, len(fe.Violations))
+	for _, v := range fe.Violations {
+		messages = append(messages, fmt.Sprintf("%s: %s", v.Field, v.Message))
+	}
+
+	return strings.Join(messages, "; ")
+}
+
 // Some more opts/configs could be added here.
 func New() *Validator {
 	vldtr := validator.New()
 
+	// Report fields by their json name, which is what clients actually send.
+	vldtr.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
 	return &Validator{vldtr}
 }
+
+// Violations flattens a validation failure into field violations. It returns nil when
+// err is neither validator.ValidationErrors nor a *FieldError.
+func Violations(err error) []FieldViolation {
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return fieldErr.Violations
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	violations := make([]FieldViolation, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		violations = append(violations, FieldViolation{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Message: ruleMessage(fe),
+		})
+	}
+
+	return violations
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", fe.Param())
+	default:
+		return fmt.Sprintf("failed on the '%s' rule", fe.Tag())
+	}
+}